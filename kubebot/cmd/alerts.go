@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Pod problems the health alerts report.
+const (
+	alertCrashLoop = "CrashLoopBackOff"
+	alertImagePull = "ImagePullBackOff"
+	alertOOMKilled = "OOMKilled"
+	alertNotReady  = "NotReady"
+)
+
+// alertRecoveryDelay is how long a problem must be gone before its recovery is
+// posted, so that containers crashing between restarts do not flap the alert.
+const alertRecoveryDelay = 5 * time.Minute
+
+// oomKilledWindow is how long after a container was OOM killed the app is
+// reported as affected, as the kill itself leaves no lasting pod state.
+const oomKilledWindow = 15 * time.Minute
+
+// AlertsConfig describes the background watcher that reports unhealthy pods
+// of the environments without anyone running a command.
+type AlertsConfig struct {
+	// Channel is the Slack channel ID alerts are posted to. Alerts are disabled when it is empty.
+	Channel string `yaml:"channel"`
+	// Interval is how often the pods are checked. Defaults to 30s.
+	Interval time.Duration `yaml:"interval"`
+	// NotReadyThreshold is how long a pod may fail its readiness before it is
+	// reported. Defaults to 5 minutes.
+	NotReadyThreshold time.Duration `yaml:"notReadyThreshold"`
+	// Mute lists the apps that are not reported, either by name in every
+	// environment or as environment/app in a single one.
+	Mute []string `yaml:"mute"`
+}
+
+// healthAlert is a problem of the pods of an app that has been reported.
+type healthAlert struct {
+	environment string
+	label       string
+	problem     string
+	pods        []string
+	since       time.Time
+	// lastSeen is the last check that found the problem.
+	lastSeen time.Time
+}
+
+// key identifies the alert across checks, so that it is posted only once.
+func (a *healthAlert) key() string {
+	return a.environment + "/" + a.label + "/" + a.problem
+}
+
+// muted reports whether alerts of the app in the environment are muted.
+func (c *AlertsConfig) muted(environment, label string) bool {
+	for _, mute := range c.Mute {
+		if mute == label || mute == environment+"/"+label {
+			return true
+		}
+	}
+	return false
+}
+
+// startHealthAlerts periodically checks the pods of every environment and
+// posts an alert when the pods of an app start crash looping, failing to pull
+// their image, getting OOM killed or failing readiness for longer than the
+// threshold, and a recovery notice once the problem has been gone for
+// alertRecoveryDelay. Each problem is posted once while it lasts. Reported problems are kept in memory, so they
+// are posted again after a restart if they still last.
+func startHealthAlerts(ctx context.Context, client *slack.Client) {
+	alerts := botConfig.Alerts
+	if alerts.Channel == "" {
+		log.Println("Health alerts are disabled: alerts.channel is not set")
+		return
+	}
+
+	active := make(map[string]*healthAlert)
+	ticker := time.NewTicker(alerts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			for i := range botConfig.Environments {
+				env := &botConfig.Environments[i]
+				pods, err := listPods(env.Namespace, labels.Everything())
+				if err != nil {
+					log.Printf("Failed to list pods in namespace %s for health alerts: %v", env.Namespace, err)
+					// Keep the alerts of the environment instead of reporting a recovery that was not observed
+					for _, alert := range active {
+						if alert.environment == env.Name {
+							alert.lastSeen = now
+						}
+					}
+					continue
+				}
+
+				for _, alert := range podHealthAlerts(env, pods, alerts, now) {
+					if previous, ok := active[alert.key()]; ok {
+						previous.pods, previous.lastSeen = alert.pods, now
+						continue
+					}
+					active[alert.key()] = alert
+					postHealthAlert(client, alerts.Channel, fmt.Sprintf(":rotating_light: `%s` in `%s`: %s on pods `%s`.", alert.label, alert.environment, describeHealthProblem(alert.problem, alerts), strings.Join(alert.pods, "`, `")))
+				}
+			}
+
+			for key, alert := range active {
+				if now.Sub(alert.lastSeen) < alertRecoveryDelay {
+					continue
+				}
+				delete(active, key)
+				postHealthAlert(client, alerts.Channel, fmt.Sprintf(":white_check_mark: `%s` in `%s` recovered from %s after %s.", alert.label, alert.environment, alert.problem, formatAge(alert.lastSeen.Sub(alert.since))))
+			}
+			activeAlerts.Set(float64(len(active)))
+		}
+	}
+}
+
+// podHealthAlerts groups the problems of the pods of an environment by app and
+// problem. Pods without an app label, terminating pods and muted apps are left out.
+func podHealthAlerts(env *Environment, pods []*corev1.Pod, alerts AlertsConfig, now time.Time) []*healthAlert {
+	byKey := make(map[string]*healthAlert)
+	var result []*healthAlert
+	for _, pod := range pods {
+		label := pod.Labels["app.kubernetes.io/name"]
+		if label == "" || pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || alerts.muted(env.Name, label) {
+			continue
+		}
+		for _, problem := range podProblems(pod, alerts.NotReadyThreshold, now) {
+			alert := &healthAlert{environment: env.Name, label: label, problem: problem, since: now, lastSeen: now}
+			if existing, ok := byKey[alert.key()]; ok {
+				alert = existing
+			} else {
+				byKey[alert.key()] = alert
+				result = append(result, alert)
+			}
+			alert.pods = append(alert.pods, pod.Name)
+		}
+	}
+	for _, alert := range result {
+		sort.Strings(alert.pods)
+	}
+	return result
+}
+
+// podProblems returns the problems of a pod, each at most once.
+func podProblems(pod *corev1.Pod, notReadyThreshold time.Duration, now time.Time) []string {
+	found := make(map[string]bool)
+	// Copied, as appending to the statuses of a cached pod would write to memory shared with the cache
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "CrashLoopBackOff":
+				found[alertCrashLoop] = true
+			case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+				found[alertImagePull] = true
+			}
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == "OOMKilled" && now.Sub(terminated.FinishedAt.Time) < oomKilledWindow {
+			found[alertOOMKilled] = true
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+			found[alertOOMKilled] = true
+		}
+	}
+
+	// Pods that are not ready because their containers crash are reported as such only
+	if !found[alertCrashLoop] && !found[alertImagePull] {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue && now.Sub(condition.LastTransitionTime.Time) > notReadyThreshold {
+				found[alertNotReady] = true
+			}
+		}
+	}
+
+	var problems []string
+	for _, problem := range []string{alertCrashLoop, alertImagePull, alertOOMKilled, alertNotReady} {
+		if found[problem] {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// describeHealthProblem explains a problem in an alert.
+func describeHealthProblem(problem string, alerts AlertsConfig) string {
+	switch problem {
+	case alertCrashLoop:
+		return "containers are in `CrashLoopBackOff`"
+	case alertImagePull:
+		return "the image cannot be pulled (`ImagePullBackOff`)"
+	case alertOOMKilled:
+		return "containers were `OOMKilled`"
+	case alertNotReady:
+		return fmt.Sprintf("pods have not been ready for more than %s", alerts.NotReadyThreshold)
+	}
+	return problem
+}
+
+// postHealthAlert posts an alert or recovery notice to the alerts channel.
+func postHealthAlert(client *slack.Client, channelID, text string) {
+	if _, _, err := client.PostMessage(channelID, slack.MsgOptionText(text, false)); err != nil {
+		log.Printf("Failed to post health alert: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodProblems(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	threshold := 5 * time.Minute
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: "kbot", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	oomKilledAt := func(finished time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: "kbot", LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(finished)}}}
+	}
+	notReadySince := func(since time.Time) []corev1.PodCondition {
+		return []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(since)}}
+	}
+
+	tests := []struct {
+		name   string
+		status corev1.PodStatus
+		want   []string
+	}{
+		{
+			name:   "healthy",
+			status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "kbot", Ready: true}}},
+		},
+		{
+			name:   "crash loop hides not ready",
+			status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("CrashLoopBackOff")}, Conditions: notReadySince(now.Add(-time.Hour))},
+			want:   []string{alertCrashLoop},
+		},
+		{
+			name:   "image pull errors",
+			status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("ErrImagePull")}},
+			want:   []string{alertImagePull},
+		},
+		{
+			name:   "init container pull failure",
+			status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{waiting("ImagePullBackOff")}},
+			want:   []string{alertImagePull},
+		},
+		{
+			name:   "recently OOM killed",
+			status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{oomKilledAt(now.Add(-time.Minute))}},
+			want:   []string{alertOOMKilled},
+		},
+		{
+			name:   "OOM killed long ago",
+			status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{oomKilledAt(now.Add(-time.Hour))}},
+		},
+		{
+			name:   "not ready below the threshold",
+			status: corev1.PodStatus{Conditions: notReadySince(now.Add(-time.Minute))},
+		},
+		{
+			name:   "not ready above the threshold",
+			status: corev1.PodStatus{Conditions: notReadySince(now.Add(-10 * time.Minute))},
+			want:   []string{alertNotReady},
+		},
+		{
+			name: "several problems are each reported once",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{waiting("CrashLoopBackOff"), oomKilledAt(now.Add(-time.Minute)), waiting("CrashLoopBackOff")},
+			},
+			want: []string{alertCrashLoop, alertOOMKilled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: tt.status}
+			if got := podProblems(pod, threshold, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podProblems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
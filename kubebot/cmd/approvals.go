@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Approval request statuses.
+const (
+	approvalPending  = "pending"
+	approvalApproved = "approved"
+	approvalRejected = "rejected"
+	approvalExpired  = "expired"
+)
+
+// Action IDs of the buttons on approval request messages.
+const (
+	approveActionID = "promotion_approve"
+	rejectActionID  = "promotion_reject"
+)
+
+// approvalExpiryInterval is how often stale approval requests are expired.
+const approvalExpiryInterval = time.Minute
+
+// ApprovalRequest is a promotion waiting for another user to approve it.
+type ApprovalRequest struct {
+	ID          int64
+	Environment string
+	Label       string
+	Version     string
+	RequestedBy string
+	ChannelID   string
+	MessageTS   string
+	Status      string
+	DecidedBy   string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DecidedAt   time.Time
+	// Force is set when the promotion was requested with --force, so that it
+	// overrides a freeze once approved if the requester is an admin.
+	Force bool
+}
+
+// ApprovalStore persists promotion approval requests, so that they survive
+// restarts and can be decided by any replica of the bot.
+type ApprovalStore interface {
+	// CreateApprovalRequest adds a new pending request and sets its ID.
+	CreateApprovalRequest(r *ApprovalRequest) error
+	// SetApprovalMessage stores the Slack message that shows the request.
+	SetApprovalMessage(id int64, channelID, messageTS string) error
+	// GetApprovalRequest returns the request with the given ID, or nil if there is none.
+	GetApprovalRequest(id int64) (*ApprovalRequest, error)
+	// DecideApprovalRequest moves a pending request to the given status and
+	// reports false if it was no longer pending.
+	DecideApprovalRequest(id int64, status, decidedBy string) (bool, error)
+	// ListExpiredApprovalRequests returns the pending requests that expired before now.
+	ListExpiredApprovalRequests(now time.Time) ([]ApprovalRequest, error)
+}
+
+// ApprovalConfig describes whether promotions to an environment need approval.
+type ApprovalConfig struct {
+	// Required makes promotions wait for a different user with the approve permission.
+	Required bool `yaml:"required"`
+	// TTL is how long a request stays open before it expires. Defaults to one hour.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// requestPromotionApproval records a pending promotion and posts the approval
+// request with Approve and Reject buttons to the channel.
+func requestPromotionApproval(env *Environment, label, version string, force bool, command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	now := time.Now().UTC()
+	request := &ApprovalRequest{
+		Environment: env.Name,
+		Label:       label,
+		Version:     version,
+		RequestedBy: command.UserID,
+		ChannelID:   command.ChannelID,
+		Force:       force,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(env.Approval.TTL),
+	}
+	if err := store.CreateApprovalRequest(request); err != nil {
+		totalErrors.WithLabelValues("/promote").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to create approval request: %s", err))
+	}
+
+	channelID, messageTS, err := client.PostMessage(command.ChannelID, slack.MsgOptionBlocks(approvalBlocks(request)...))
+	if err != nil {
+		// Nobody can see the buttons, so the request must not stay pending
+		if _, decideErr := store.DecideApprovalRequest(request.ID, approvalExpired, ""); decideErr != nil {
+			log.Printf("Failed to expire unposted approval request %d: %v", request.ID, decideErr)
+		}
+		totalErrors.WithLabelValues("/promote").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to post approval request: %s", err))
+	}
+
+	if err := store.SetApprovalMessage(request.ID, channelID, messageTS); err != nil {
+		log.Printf("Failed to store message of approval request %d: %v", request.ID, err)
+	}
+	return nil, nil
+}
+
+// handleApprovalAction handles a click on the Approve or Reject button of an approval request.
+func handleApprovalAction(action *slack.BlockAction, interaction slack.InteractionCallback, client *slack.Client) error {
+	userID := interaction.User.ID
+	channelID := interaction.Channel.ID
+
+	id, err := strconv.ParseInt(action.Value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid approval request ID %q: %w", action.Value, err)
+	}
+
+	request, err := store.GetApprovalRequest(id)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		return postEphemeral(client, channelID, userID, "This approval request no longer exists.")
+	}
+	if request.Status != approvalPending {
+		return postEphemeral(client, channelID, userID, fmt.Sprintf("This approval request is already %s.", request.Status))
+	}
+
+	// Expire the request on the spot if the janitor hasn't done so yet
+	if time.Now().After(request.ExpiresAt) {
+		if err := decideApprovalRequest(request, approvalExpired, "", client); err != nil {
+			return err
+		}
+		return postEphemeral(client, channelID, userID, "This approval request has expired. Please run the promotion again.")
+	}
+
+	env := botConfig.environment(request.Environment)
+	app := botConfig.app(request.Label)
+	if env == nil || app == nil {
+		return postEphemeral(client, channelID, userID, fmt.Sprintf("Environment `%s` or app `%s` is no longer configured.", request.Environment, request.Label))
+	}
+
+	if action.ActionID == rejectActionID {
+		// The requester may withdraw their own request
+		if userID != request.RequestedBy {
+			if err := botConfig.authorize(userID, env, "approve"); err != nil {
+				return postEphemeral(client, channelID, userID, fmt.Sprintf("Access denied: %s.", err))
+			}
+		}
+		return decideApprovalRequest(request, approvalRejected, userID, client)
+	}
+
+	if userID == request.RequestedBy {
+		return postEphemeral(client, channelID, userID, "You cannot approve your own promotion. Please ask another approver.")
+	}
+	if err := botConfig.authorize(userID, env, "approve"); err != nil {
+		return postEphemeral(client, channelID, userID, fmt.Sprintf("Access denied: %s.", err))
+	}
+
+	// The environment may have been frozen since the promotion was requested; only a --force of an admin requester overrides the freeze
+	if err := checkFreeze(env, request.RequestedBy, "/promote", request.Force); err != nil {
+		return postEphemeral(client, channelID, userID, fmt.Sprintf("%s.", err))
+	}
+
+	if err := decideApprovalRequest(request, approvalApproved, userID, client); err != nil {
+		return err
+	}
+
+	commandText := fmt.Sprintf("/promote %s %s", request.Environment, request.Label)
+	_, err = executePromotion(app, env, request.Label, request.Version, commandText, client, request.ChannelID, request.RequestedBy)
+	return err
+}
+
+// decideApprovalRequest moves the request to the given status and updates its
+// Slack message. Requests that were decided concurrently are left untouched.
+func decideApprovalRequest(request *ApprovalRequest, status, decidedBy string, client *slack.Client) error {
+	decided, err := store.DecideApprovalRequest(request.ID, status, decidedBy)
+	if err != nil {
+		return err
+	}
+	if !decided {
+		return postEphemeral(client, request.ChannelID, decidedBy, "This approval request was already decided by someone else.")
+	}
+
+	request.Status, request.DecidedBy, request.DecidedAt = status, decidedBy, time.Now().UTC()
+	if request.MessageTS == "" {
+		return nil
+	}
+	_, _, _, err = client.UpdateMessage(request.ChannelID, request.MessageTS, slack.MsgOptionBlocks(approvalBlocks(request)...))
+	if err != nil {
+		return fmt.Errorf("failed to update approval request message: %w", err)
+	}
+	return nil
+}
+
+// approvalBlocks renders the Block Kit message of an approval request: with
+// Approve and Reject buttons while it is pending, and with the decision afterwards.
+func approvalBlocks(request *ApprovalRequest) []slack.Block {
+	text := fmt.Sprintf("*Promotion approval required*\n<@%s> requested promotion of `%s` version `%s` to `%s`.",
+		request.RequestedBy, request.Label, request.Version, request.Environment)
+	if request.Force {
+		text += fmt.Sprintf(" The promotion overrides a freeze of the environment (`%s`).", forceFlag)
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+	}
+
+	switch request.Status {
+	case approvalPending:
+		approve := slack.NewButtonBlockElement(approveActionID, strconv.FormatInt(request.ID, 10), slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false))
+		approve.Style = slack.StylePrimary
+		reject := slack.NewButtonBlockElement(rejectActionID, strconv.FormatInt(request.ID, 10), slack.NewTextBlockObject(slack.PlainTextType, "Reject", false, false))
+		reject.Style = slack.StyleDanger
+		blocks = append(blocks,
+			slack.NewActionBlock(fmt.Sprintf("approval_%d", request.ID), approve, reject),
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Expires at %s", request.ExpiresAt.Format("2006-01-02 15:04:05 MST")), false, false)),
+		)
+	case approvalExpired:
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf(":hourglass: Expired at %s without a decision", request.ExpiresAt.Format("2006-01-02 15:04:05 MST")), false, false)))
+	case approvalApproved:
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf(":white_check_mark: Approved by <@%s> at %s", request.DecidedBy, request.DecidedAt.Format("2006-01-02 15:04:05 MST")), false, false)))
+	case approvalRejected:
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf(":x: Rejected by <@%s> at %s", request.DecidedBy, request.DecidedAt.Format("2006-01-02 15:04:05 MST")), false, false)))
+	}
+
+	return blocks
+}
+
+// startApprovalExpiry periodically expires stale approval requests and updates
+// their messages until the context is cancelled.
+func startApprovalExpiry(ctx context.Context, client *slack.Client) {
+	ticker := time.NewTicker(approvalExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			requests, err := store.ListExpiredApprovalRequests(time.Now())
+			if err != nil {
+				log.Printf("Failed to list expired approval requests: %v", err)
+				continue
+			}
+			for i := range requests {
+				if err := decideApprovalRequest(&requests[i], approvalExpired, "", client); err != nil {
+					log.Printf("Failed to expire approval request %d: %v", requests[i].ID, err)
+				}
+			}
+		}
+	}
+}
+
+// postEphemeral posts a message only the given user can see.
+func postEphemeral(client *slack.Client, channelID, userID, text string) error {
+	if userID == "" {
+		return nil
+	}
+	_, err := client.PostEphemeral(channelID, userID, slack.MsgOptionText(text, false))
+	if err != nil {
+		return fmt.Errorf("failed to post ephemeral message: %w", err)
+	}
+	return nil
+}
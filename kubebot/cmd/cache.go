@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// cacheResyncPeriod is how often the informers replay their cached objects.
+const cacheResyncPeriod = 10 * time.Minute
+
+// cacheSyncTimeout bounds how long start waits for the initial listing.
+const cacheSyncTimeout = time.Minute
+
+// namespaceCache serves the pods, deployments and replica sets of a namespace
+// from a shared informer. Objects returned by the listers are shared with the
+// cache and must not be modified.
+type namespaceCache struct {
+	pods        corelisters.PodNamespaceLister
+	deployments appslisters.DeploymentNamespaceLister
+	replicaSets appslisters.ReplicaSetNamespaceLister
+}
+
+// kubeCache holds a cache for every namespace of the configured environments.
+// Reads in other namespaces go to the API server.
+var kubeCache = map[string]*namespaceCache{}
+
+// initKubernetesCache starts the informers of the configured namespaces and
+// waits for their initial listing. They stop when the context is cancelled.
+func initKubernetesCache(ctx context.Context) {
+	for _, env := range botConfig.Environments {
+		namespace := env.Namespace
+		if _, ok := kubeCache[namespace]; ok {
+			continue
+		}
+
+		factory := informers.NewSharedInformerFactoryWithOptions(clientset, cacheResyncPeriod, informers.WithNamespace(namespace))
+		podInformer := factory.Core().V1().Pods()
+		deploymentInformer := factory.Apps().V1().Deployments()
+		replicaSetInformer := factory.Apps().V1().ReplicaSets()
+		nsCache := &namespaceCache{
+			pods:        podInformer.Lister().Pods(namespace),
+			deployments: deploymentInformer.Lister().Deployments(namespace),
+			replicaSets: replicaSetInformer.Lister().ReplicaSets(namespace),
+		}
+		factory.Start(ctx.Done())
+
+		syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+		synced := cache.WaitForCacheSync(syncCtx.Done(), podInformer.Informer().HasSynced, deploymentInformer.Informer().HasSynced, replicaSetInformer.Informer().HasSynced)
+		cancel()
+		if !synced {
+			log.Fatalf("Failed to sync the Kubernetes cache of namespace %s", namespace)
+		}
+
+		kubeCache[namespace] = nsCache
+		log.Printf("Kubernetes cache of namespace %s is synced", namespace)
+	}
+}
+
+// listPods returns the pods in the namespace matching the selector.
+func listPods(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	if nsCache, ok := kubeCache[namespace]; ok {
+		return nsCache.pods.List(selector)
+	}
+
+	list, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*corev1.Pod, len(list.Items))
+	for i := range list.Items {
+		pods[i] = &list.Items[i]
+	}
+	return pods, nil
+}
+
+// getPod returns the named pod in the namespace.
+func getPod(namespace, name string) (*corev1.Pod, error) {
+	if nsCache, ok := kubeCache[namespace]; ok {
+		return nsCache.pods.Get(name)
+	}
+	return clientset.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// listDeployments returns the deployments in the namespace.
+func listDeployments(namespace string) ([]*appsv1.Deployment, error) {
+	if nsCache, ok := kubeCache[namespace]; ok {
+		return nsCache.deployments.List(labels.Everything())
+	}
+
+	list, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]*appsv1.Deployment, len(list.Items))
+	for i := range list.Items {
+		deployments[i] = &list.Items[i]
+	}
+	return deployments, nil
+}
+
+// listReplicaSets returns the replica sets in the namespace matching the selector.
+func listReplicaSets(namespace string, selector labels.Selector) ([]*appsv1.ReplicaSet, error) {
+	if nsCache, ok := kubeCache[namespace]; ok {
+		return nsCache.replicaSets.List(selector)
+	}
+
+	list, err := clientset.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := make([]*appsv1.ReplicaSet, len(list.Items))
+	for i := range list.Items {
+		replicaSets[i] = &list.Items[i]
+	}
+	return replicaSets, nil
+}
+
+// appSelector selects the pods of an app by their app.kubernetes.io/name label.
+func appSelector(label string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{"app.kubernetes.io/name": label})
+}
@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// botConfig is a global variable that holds the configuration loaded at start,
+// describing the environments the bot manages and the commands allowed in each.
+var botConfig *Config
+
+// configPath is the path to the YAML configuration file passed with --config.
+var configPath string
+
+// Config is the root of the Slackbot YAML configuration file.
+type Config struct {
+	// Environments lists the environments in promotion order, e.g. dev -> qa -> stage -> prod.
+	Environments []Environment `yaml:"environments"`
+	// Apps registers the applications the bot can promote, keyed by their
+	// app.kubernetes.io/name label, together with their GitOps files.
+	Apps []App `yaml:"apps"`
+	// Database selects the release history storage backend.
+	Database DatabaseConfig `yaml:"database"`
+	// RBAC maps Slack users to roles checked before commands act.
+	RBAC RBACConfig `yaml:"rbac"`
+	// GitHub tunes how pull requests opened by the bot are followed.
+	GitHub GitHubConfig `yaml:"github"`
+	// Rollout bounds how long rollouts are watched.
+	Rollout RolloutConfig `yaml:"rollout"`
+	// Logs tunes the /logs command.
+	Logs LogsConfig `yaml:"logs"`
+	// Alerts reports unhealthy pods of the environments to a channel.
+	Alerts AlertsConfig `yaml:"alerts"`
+	// Drift reports apps whose pods do not run the version pinned in the GitOps repository.
+	Drift DriftConfig `yaml:"drift"`
+}
+
+// DatabaseConfig describes where the release history is stored.
+type DatabaseConfig struct {
+	// Driver is one of sqlite, postgres or memory. Defaults to sqlite.
+	Driver string `yaml:"driver"`
+	// DSN is the SQLite file path or the PostgreSQL connection string. The
+	// SLACKBOT_DATABASE_DSN environment variable takes precedence, so that
+	// credentials can be kept out of the config file.
+	DSN string `yaml:"dsn"`
+}
+
+// Environment describes a single deployment environment.
+type Environment struct {
+	// Name is the environment name users pass to the slash commands.
+	Name string `yaml:"name"`
+	// Namespace is the Kubernetes namespace of the environment. Defaults to Name.
+	Namespace string `yaml:"namespace"`
+	// Upstream is the environment versions are promoted from. Defaults to the
+	// previous environment in the list.
+	Upstream string `yaml:"upstream"`
+	// Commands lists the slash commands (without the leading slash) allowed in the environment.
+	Commands []string `yaml:"commands"`
+	// Roles overrides the minimum role needed to run a command in the
+	// environment, keyed by command name without the leading slash.
+	Roles map[string]string `yaml:"roles"`
+	// Users overrides the global role of Slack users in the environment.
+	Users map[string]string `yaml:"users"`
+	// Approval makes promotions to the environment wait for approval by another user.
+	Approval ApprovalConfig `yaml:"approval"`
+	// Scale limits the replicas /scale may set.
+	Scale ScaleConfig `yaml:"scale"`
+	// Freeze tunes what /freeze does besides blocking promotions and rollbacks.
+	Freeze FreezeConfig `yaml:"freeze"`
+	// Soak watches promoted versions after their rollout and rolls them back when they are unhealthy.
+	Soak SoakConfig `yaml:"soak"`
+}
+
+// App describes where the GitOps manifests of a single application live.
+type App struct {
+	// Name is the value of the app.kubernetes.io/name label of the application pods.
+	Name string `yaml:"name"`
+	// Owner is the owner of the GitOps repository. Defaults to GITHUB_OWNER.
+	Owner string `yaml:"owner"`
+	// Repo is the name of the GitOps repository. Defaults to GITHUB_REPO.
+	Repo string `yaml:"repo"`
+	// Branch is the branch the manifests are read from and committed to. Defaults to main.
+	Branch string `yaml:"branch"`
+	// Branches overrides Branch for individual environments, keyed by environment name.
+	Branches map[string]string `yaml:"branches"`
+	// Path is a text/template of the ImagePolicy file path. It may reference
+	// {{.App}}, {{.Environment}} and {{.Namespace}}.
+	Path string `yaml:"path"`
+	// ImagePolicy is the name of the ImagePolicy object in the file. Defaults to Name.
+	ImagePolicy string `yaml:"imagePolicy"`
+	// Mode is direct to commit changes to Branch, or pull_request to open a
+	// pull request against it. Defaults to direct.
+	Mode string `yaml:"mode"`
+	// Container is the name of the primary container whose image tag is the
+	// app version, so that sidecars are not mistaken for the app. Defaults to
+	// the first container of the pod whose image has a version tag.
+	Container string `yaml:"container"`
+	// Reconcile lists the Flux objects reconciled right after a promotion or
+	// rollback commits the version, instead of waiting for their interval.
+	Reconcile []FluxReference `yaml:"reconcile"`
+
+	pathTemplate *template.Template
+}
+
+// defaultConfig returns the configuration used when no config file is given.
+// It matches the dev -> qa -> stage -> prod pipeline the bot was built for.
+func defaultConfig() *Config {
+	return &Config{
+		Environments: []Environment{
+			{Name: "dev", Commands: []string{"list", "diff", "logs", "events", "describe", "flux", "reconcile", "restart", "scale", "freeze", "unfreeze"}},
+			{Name: "qa", Commands: []string{"list", "diff", "logs", "events", "describe", "flux", "drift", "reconcile", "promote", "rollback", "restart", "scale", "freeze", "unfreeze"}},
+			{Name: "stage", Commands: []string{"list", "diff", "logs", "events", "describe", "flux", "drift", "reconcile", "promote", "rollback", "restart", "scale", "freeze", "unfreeze"}},
+			{Name: "prod", Commands: []string{"list", "diff", "logs", "events", "describe", "flux", "drift", "reconcile", "promote", "rollback", "restart", "scale", "freeze", "unfreeze"}, Approval: ApprovalConfig{Required: true}},
+		},
+		Apps: []App{
+			{
+				Name:     "kbot",
+				Branch:   "main",
+				Branches: map[string]string{"prod": "prod"},
+				Path:     "clusters/kbot/{{.Namespace}}/image-policy.yaml",
+			},
+		},
+	}
+}
+
+// loadConfig reads the configuration from the given YAML file. An empty path
+// yields the default configuration.
+func loadConfig(path string) (*Config, error) {
+	if path == "" {
+		log.Println("No config file given, using the default environments")
+		cfg := defaultConfig()
+		return cfg, cfg.validate()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	log.Printf("Loaded config from %s with %d environments and %d apps", path, len(cfg.Environments), len(cfg.Apps))
+	return cfg, nil
+}
+
+// initConfig loads the configuration into botConfig, exiting on failure.
+func initConfig() {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	botConfig = cfg
+}
+
+// validate fills in defaults and checks that environments are consistent.
+func (c *Config) validate() error {
+	if len(c.Environments) == 0 {
+		return fmt.Errorf("at least one environment must be defined")
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Environments {
+		env := &c.Environments[i]
+		if env.Name == "" {
+			return fmt.Errorf("environment #%d has no name", i+1)
+		}
+		if seen[env.Name] {
+			return fmt.Errorf("environment %s is defined more than once", env.Name)
+		}
+		seen[env.Name] = true
+
+		if env.Namespace == "" {
+			env.Namespace = env.Name
+		}
+		if env.Approval.TTL == 0 {
+			env.Approval.TTL = time.Hour
+		}
+		if env.Upstream == "" && i > 0 {
+			env.Upstream = c.Environments[i-1].Name
+		}
+		if env.Scale.MinReplicas == 0 && env.Scale.MaxReplicas == 0 {
+			env.Scale = ScaleConfig{MinReplicas: 1, MaxReplicas: 10}
+		}
+		if env.Scale.MinReplicas < 0 || env.Scale.MaxReplicas < env.Scale.MinReplicas {
+			return fmt.Errorf("environment %s has invalid scale limits %d-%d", env.Name, env.Scale.MinReplicas, env.Scale.MaxReplicas)
+		}
+		// Unset soak thresholds get their defaults, while an explicit 0 is kept
+		if env.Soak.MaxRestarts == nil {
+			maxRestarts := int32(3)
+			env.Soak.MaxRestarts = &maxRestarts
+		}
+		if env.Soak.MaxUnready == nil {
+			maxUnready := 2 * time.Minute
+			env.Soak.MaxUnready = &maxUnready
+		}
+		if env.Soak.Duration < 0 || *env.Soak.MaxRestarts < 0 || *env.Soak.MaxUnready < 0 {
+			return fmt.Errorf("environment %s has negative soak settings", env.Name)
+		}
+	}
+
+	if c.RBAC.DefaultRole == "" {
+		c.RBAC.DefaultRole = roleViewer.String()
+	}
+	if _, err := parseRole(c.RBAC.DefaultRole); err != nil {
+		return fmt.Errorf("rbac defaultRole: %w", err)
+	}
+	if !c.RBAC.enabled() {
+		log.Println("WARNING: rbac.enabled is false, every Slack user is an admin in every environment")
+	} else if c.RBAC.Enabled == nil && len(c.RBAC.Users) == 0 {
+		log.Printf("RBAC is enabled by default and no users are listed, so every Slack user has the %s role. Set rbac.users to grant more", c.RBAC.DefaultRole)
+	}
+	if err := validateRoles(c.RBAC.Users, "rbac user"); err != nil {
+		return err
+	}
+
+	for _, env := range c.Environments {
+		if err := validateRoles(env.Roles, fmt.Sprintf("environment %s command", env.Name)); err != nil {
+			return err
+		}
+		if err := validateRoles(env.Users, fmt.Sprintf("environment %s user", env.Name)); err != nil {
+			return err
+		}
+
+		if env.Upstream == "" {
+			if env.allows("promote") {
+				return fmt.Errorf("environment %s allows promote but has no upstream", env.Name)
+			}
+			continue
+		}
+		if !seen[env.Upstream] {
+			return fmt.Errorf("environment %s has unknown upstream %s", env.Name, env.Upstream)
+		}
+		if env.Upstream == env.Name {
+			return fmt.Errorf("environment %s cannot be its own upstream", env.Name)
+		}
+	}
+
+	if c.Database.Driver == "" {
+		c.Database.Driver = "sqlite"
+	}
+	if dsn := os.Getenv("SLACKBOT_DATABASE_DSN"); dsn != "" {
+		c.Database.DSN = dsn
+	}
+	if c.Database.DSN == "" && c.Database.Driver == "sqlite" {
+		c.Database.DSN = "./data/history.db"
+	}
+
+	if err := c.Logs.compile(); err != nil {
+		return err
+	}
+
+	if c.Alerts.Interval == 0 {
+		c.Alerts.Interval = 30 * time.Second
+	}
+	if c.Alerts.NotReadyThreshold == 0 {
+		c.Alerts.NotReadyThreshold = 5 * time.Minute
+	}
+	if c.Alerts.Interval < 0 || c.Alerts.NotReadyThreshold < 0 {
+		return fmt.Errorf("alerts interval and notReadyThreshold must be positive")
+	}
+
+	if c.Drift.Interval == 0 {
+		c.Drift.Interval = 10 * time.Minute
+	}
+	if c.Drift.Threshold == 0 {
+		c.Drift.Threshold = 15 * time.Minute
+	}
+	if c.Drift.Interval < 0 || c.Drift.Threshold < 0 {
+		return fmt.Errorf("drift interval and threshold must be positive")
+	}
+
+	if c.Rollout.Timeout == 0 {
+		c.Rollout.Timeout = 15 * time.Minute
+	}
+	if c.GitHub.PullRequestPollInterval == 0 {
+		c.GitHub.PullRequestPollInterval = 30 * time.Second
+	}
+	if c.GitHub.PullRequestTimeout == 0 {
+		c.GitHub.PullRequestTimeout = 24 * time.Hour
+	}
+
+	apps := make(map[string]bool)
+	for i := range c.Apps {
+		app := &c.Apps[i]
+		if app.Name == "" {
+			return fmt.Errorf("app #%d has no name", i+1)
+		}
+		if apps[app.Name] {
+			return fmt.Errorf("app %s is defined more than once", app.Name)
+		}
+		apps[app.Name] = true
+
+		if app.Owner == "" {
+			app.Owner = os.Getenv("GITHUB_OWNER")
+		}
+		if app.Repo == "" {
+			app.Repo = os.Getenv("GITHUB_REPO")
+		}
+		if app.Branch == "" {
+			app.Branch = "main"
+		}
+		if app.ImagePolicy == "" {
+			app.ImagePolicy = app.Name
+		}
+		switch app.Mode {
+		case "":
+			app.Mode = promotionModeDirect
+		case promotionModeDirect, promotionModePullRequest:
+		default:
+			return fmt.Errorf("app %s has unknown mode %s, expected %s or %s", app.Name, app.Mode, promotionModeDirect, promotionModePullRequest)
+		}
+		for envName := range app.Branches {
+			if !seen[envName] {
+				return fmt.Errorf("app %s has a branch for unknown environment %s", app.Name, envName)
+			}
+		}
+		if app.Path == "" {
+			return fmt.Errorf("app %s has no path", app.Name)
+		}
+		tmpl, err := template.New(app.Name).Option("missingkey=error").Parse(app.Path)
+		if err != nil {
+			return fmt.Errorf("app %s has an invalid path template: %w", app.Name, err)
+		}
+		app.pathTemplate = tmpl
+
+		for _, ref := range app.Reconcile {
+			if _, ok := reconcileKinds[ref.Kind]; !ok {
+				return fmt.Errorf("app %s reconciles unknown kind %s, expected %s", app.Name, ref.Kind, reconcileKindNames)
+			}
+			if ref.Name == "" {
+				return fmt.Errorf("app %s reconciles a %s without a name", app.Name, ref.Kind)
+			}
+		}
+	}
+
+	return nil
+}
+
+// app returns the app registered under the given label, or nil if it is not configured.
+func (c *Config) app(name string) *App {
+	for i := range c.Apps {
+		if c.Apps[i].Name == name {
+			return &c.Apps[i]
+		}
+	}
+	return nil
+}
+
+// appNames returns a comma separated list of the registered apps, for use in user facing messages.
+func (c *Config) appNames() string {
+	var names []string
+	for _, app := range c.Apps {
+		names = append(names, app.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// environment returns the environment with the given name, or nil if it is not configured.
+func (c *Config) environment(name string) *Environment {
+	for i := range c.Environments {
+		if c.Environments[i].Name == name {
+			return &c.Environments[i]
+		}
+	}
+	return nil
+}
+
+// environmentsAllowing returns, in pipeline order, the environments where the command is allowed.
+func (c *Config) environmentsAllowing(command string) []*Environment {
+	var envs []*Environment
+	for i := range c.Environments {
+		if c.Environments[i].allows(command) {
+			envs = append(envs, &c.Environments[i])
+		}
+	}
+	return envs
+}
+
+// environmentNamesAllowing returns a comma separated list of environments where
+// the command is allowed, for use in user facing messages.
+func (c *Config) environmentNamesAllowing(command string) string {
+	var names []string
+	for _, env := range c.environmentsAllowing(command) {
+		names = append(names, env.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// allows reports whether the command (with or without the leading slash) is allowed in the environment.
+func (e *Environment) allows(command string) bool {
+	command = strings.TrimPrefix(command, "/")
+	for _, allowed := range e.Commands {
+		if strings.TrimPrefix(allowed, "/") == command {
+			return true
+		}
+	}
+	return false
+}
+
+// branchFor returns the GitOps branch of the app in the given environment.
+func (a *App) branchFor(env *Environment) string {
+	if branch, ok := a.Branches[env.Name]; ok {
+		return branch
+	}
+	return a.Branch
+}
+
+// pathFor renders the ImagePolicy file path of the app in the given environment.
+func (a *App) pathFor(env *Environment) (string, error) {
+	var buf bytes.Buffer
+	data := struct {
+		App         string
+		Environment string
+		Namespace   string
+	}{a.Name, env.Name, env.Namespace}
+	if err := a.pathTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render path for app %s: %w", a.Name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	t.Setenv("SLACKBOT_DATABASE_DSN", "")
+
+	// pipeline returns a valid dev -> prod config that the cases modify
+	pipeline := func() *Config {
+		return &Config{
+			Environments: []Environment{
+				{Name: "dev", Commands: []string{"list"}},
+				{Name: "prod", Commands: []string{"list", "promote"}},
+			},
+			Apps: []App{{Name: "kbot", Path: "clusters/{{.Namespace}}/image-policy.yaml"}},
+		}
+	}
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{name: "no environments", modify: func(c *Config) { c.Environments = nil }, wantErr: "at least one environment"},
+		{name: "environment without a name", modify: func(c *Config) { c.Environments[1].Name = "" }, wantErr: "has no name"},
+		{name: "duplicate environment", modify: func(c *Config) { c.Environments[1].Name = "dev" }, wantErr: "defined more than once"},
+		{name: "promote without upstream", modify: func(c *Config) { c.Environments[0].Commands = []string{"promote"} }, wantErr: "has no upstream"},
+		{name: "unknown upstream", modify: func(c *Config) { c.Environments[1].Upstream = "qa" }, wantErr: "unknown upstream"},
+		{name: "own upstream", modify: func(c *Config) { c.Environments[1].Upstream = "prod" }, wantErr: "its own upstream"},
+		{name: "invalid scale limits", modify: func(c *Config) { c.Environments[1].Scale = ScaleConfig{MinReplicas: 5, MaxReplicas: 2} }, wantErr: "invalid scale limits"},
+		{name: "negative soak", modify: func(c *Config) { c.Environments[1].Soak.Duration = -time.Minute }, wantErr: "negative soak"},
+		{name: "unknown default role", modify: func(c *Config) { c.RBAC.DefaultRole = "owner" }, wantErr: "rbac defaultRole"},
+		{name: "unknown user role", modify: func(c *Config) { c.Environments[1].Users = map[string]string{"U1": "owner"} }, wantErr: "environment prod user U1"},
+		{name: "negative alert interval", modify: func(c *Config) { c.Alerts.Interval = -time.Second }, wantErr: "alerts interval"},
+		{name: "negative drift threshold", modify: func(c *Config) { c.Drift.Threshold = -time.Second }, wantErr: "drift interval"},
+		{name: "duplicate app", modify: func(c *Config) { c.Apps = append(c.Apps, c.Apps[0]) }, wantErr: "app kbot is defined more than once"},
+		{name: "app without a path", modify: func(c *Config) { c.Apps[0].Path = "" }, wantErr: "has no path"},
+		{name: "invalid path template", modify: func(c *Config) { c.Apps[0].Path = "{{.Namespace" }, wantErr: "invalid path template"},
+		{name: "unknown mode", modify: func(c *Config) { c.Apps[0].Mode = "merge" }, wantErr: "unknown mode"},
+		{name: "branch of an unknown environment", modify: func(c *Config) { c.Apps[0].Branches = map[string]string{"qa": "qa"} }, wantErr: "unknown environment qa"},
+		{name: "reconcile of an unknown kind", modify: func(c *Config) { c.Apps[0].Reconcile = []FluxReference{{Kind: "helmrelease", Name: "kbot"}} }, wantErr: "unknown kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := pipeline()
+			tt.modify(c)
+			err := c.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateDefaults(t *testing.T) {
+	t.Setenv("SLACKBOT_DATABASE_DSN", "")
+
+	c := defaultConfig()
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	prod := c.environment("prod")
+	if prod.Namespace != "prod" || prod.Upstream != "stage" {
+		t.Errorf("prod namespace, upstream = %s, %s, want prod, stage", prod.Namespace, prod.Upstream)
+	}
+	if prod.Approval.TTL != time.Hour || prod.Scale != (ScaleConfig{MinReplicas: 1, MaxReplicas: 10}) {
+		t.Errorf("prod approval TTL, scale = %s, %+v, want 1h, 1-10", prod.Approval.TTL, prod.Scale)
+	}
+	if *prod.Soak.MaxRestarts != 3 || *prod.Soak.MaxUnready != 2*time.Minute {
+		t.Errorf("prod soak = %d restarts and %s unready, want 3 restarts and 2m unready", *prod.Soak.MaxRestarts, *prod.Soak.MaxUnready)
+	}
+
+	// An explicit 0 tolerates no restarts or unready time instead of getting the defaults
+	var zeroRestarts int32
+	var zeroUnready time.Duration
+	c = defaultConfig()
+	c.Environments[3].Soak = SoakConfig{MaxRestarts: &zeroRestarts, MaxUnready: &zeroUnready}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if soak := c.environment("prod").Soak; *soak.MaxRestarts != 0 || *soak.MaxUnready != 0 {
+		t.Errorf("prod soak = %d restarts and %s unready, want the explicit 0 to be kept", *soak.MaxRestarts, *soak.MaxUnready)
+	}
+	if c.Database.Driver != "sqlite" || c.RBAC.DefaultRole != "viewer" {
+		t.Errorf("database driver, default role = %s, %s, want sqlite, viewer", c.Database.Driver, c.RBAC.DefaultRole)
+	}
+	if app := c.app("kbot"); app.Mode != promotionModeDirect || app.ImagePolicy != "kbot" {
+		t.Errorf("kbot mode, image policy = %s, %s, want %s, kbot", app.Mode, app.ImagePolicy, promotionModeDirect)
+	}
+}
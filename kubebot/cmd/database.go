@@ -2,97 +2,280 @@ package cmd
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	_ "github.com/mattn/go-sqlite3" // Import go-sqlite3 library for SQLite database interaction
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"           // Import lib/pq library for PostgreSQL database interaction
+	"github.com/mattn/go-sqlite3" // Import go-sqlite3 library for SQLite database interaction
 )
 
-var db *sql.DB // Global variable to hold the database connection
+// store is a global variable that holds the storage backend selected in the config.
+var store Store
 
-// Ensures the data directory exists, creates it if not.
-func ensureDataDir() {
-	if _, err := os.Stat("./data"); os.IsNotExist(err) {
-		err := os.Mkdir("./data", 0755)
-		if err != nil {
-			log.Fatalf("Failed to create ./data directory: %v", err)
-		}
-	}
+// Store is the storage backend of the bot: the release history, the pending
+// promotion approvals, the environment freezes and the observed drifts.
+type Store interface {
+	ReleaseStore
+	ApprovalStore
+	FreezeStore
+	DriftStore
+}
+
+// Actions recorded in the release history.
+const (
+	historyRelease  = "release" // Entries recorded before actions were tracked
+	historyPromote  = "promote"
+	historyRollback = "rollback"
+	historyRestart  = "restart"
+	historyScale    = "scale"
+)
+
+// deploysVersion reports whether entries of the action deployed their version,
+// i.e. whether they count when looking for the version to roll back to.
+func deploysVersion(action string) bool {
+	return action == historyRelease || action == historyPromote
+}
+
+// Release is a single entry of the release history.
+type Release struct {
+	ID          int64
+	Namespace   string
+	Version     string
+	Label       string
+	ReleaseTime time.Time
+	// Action is what happened, e.g. promote or restart.
+	Action string
+	// Actor is the Slack user ID of who did it, empty for the bot itself.
+	Actor string
+	// Details describes actions that do not change the version, e.g. "replicas 2 -> 4".
+	Details string
+}
+
+// ReleaseStore is the storage backend for the release history used by
+// /promote and /rollback.
+type ReleaseStore interface {
+	// AddReleaseHistory records an action, e.g. a promotion or a restart, by the
+	// actor on the version of the app in the namespace. ID and ReleaseTime are
+	// set by the store.
+	AddReleaseHistory(r Release) error
+	// GetPreviousVersion returns the version released to the namespace before
+	// currentVersion, or an empty string if there is none. Only entries that
+	// deployed their version count: rollbacks are skipped, so that rolling back
+	// again does not return to the version that was rolled back from, and so
+	// are restarts and scaling, which keep the version that is running.
+	GetPreviousVersion(namespace, currentVersion, label string) (string, error)
+	// ListReleaseHistory returns up to limit of the latest releases of the app
+	// in the namespace, newest first.
+	ListReleaseHistory(namespace, label string, limit int) ([]Release, error)
+	// Migrate applies the pending schema migrations.
+	Migrate() error
+	// MigrationStatus reports every known schema migration and whether it has been applied.
+	MigrationStatus() ([]MigrationState, error)
+	// Close releases the resources held by the store.
+	Close() error
 }
 
-// Initializes the SQLite database, creates it if it doesn't exist.
+// initDatabase opens the release history store configured in the database section
+// of the config and brings its schema up to date.
 func initDatabase() {
-	ensureDataDir()
 	var err error
-	db, err = sql.Open("sqlite3", "./data/history.db")
+	store, err = openStore(botConfig.Database)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := store.Migrate(); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	// Create the release_history table if it doesn't exist
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS release_history (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		namespace TEXT,
-		version TEXT,
-		label TEXT,
-		release_time DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`)
+// openStore returns the store for the given database config.
+func openStore(cfg DatabaseConfig) (Store, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		return openSQLiteStore(cfg.DSN)
+	case "postgres":
+		return openPostgresStore(cfg.DSN)
+	case "memory":
+		log.Println("Using in-memory release history; it will be lost on restart")
+		return newMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// sqlDialect holds the differences between the SQL databases the bot supports.
+type sqlDialect struct {
+	name string
+	// numberedParams is true when the database uses $1, $2... placeholders instead of ?.
+	numberedParams bool
+	// migrations is the directory under migrations/ holding the schema migrations of the dialect.
+	migrations string
+	// createMigrationsTable creates the schema_migrations table if it doesn't exist.
+	createMigrationsTable string
+	// migrationsTableExists counts the schema_migrations tables, i.e. returns 1 once it has been created.
+	migrationsTableExists string
+	// returningID is true when inserts report the new row ID with RETURNING id instead of LastInsertId.
+	returningID bool
+	// lock and unlock, when set, serialize migrations between bot replicas.
+	lock, unlock string
+}
 
+var sqliteDialect = sqlDialect{
+	name:       "sqlite3",
+	migrations: "sqlite",
+	createMigrationsTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`,
+	migrationsTableExists: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations';",
+}
+
+var postgresDialect = sqlDialect{
+	name:           "postgres",
+	numberedParams: true,
+	returningID:    true,
+	migrations:     "postgres",
+	createMigrationsTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`,
+	migrationsTableExists: "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations';",
+	// An arbitrary application-wide key, shared by all replicas of the bot
+	lock:   "SELECT pg_advisory_lock(7355608);",
+	unlock: "SELECT pg_advisory_unlock(7355608);",
+}
+
+// rebind rewrites ? placeholders into the placeholder style of the dialect.
+func (d sqlDialect) rebind(query string) string {
+	if !d.numberedParams {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlStore is a Store backed by a database/sql connection.
+type sqlStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// openSQLiteStore opens the SQLite database at path, creating it and its directory if they don't exist.
+func openSQLiteStore(path string) (*sqlStore, error) {
+	if path == "" {
+		path = "./data/history.db"
+	}
+	if err := ensureDataDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(sqliteDialect.name, path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("failed to open SQLite database %s: %w", path, err)
 	}
+	return &sqlStore{db: db, dialect: sqliteDialect}, nil
 }
 
-// Adds a new entry to the release_history table in the database.
-func AddReleaseHistory(namespace, version, label string) error {
-	_, err := db.Exec(`
-        INSERT INTO release_history (namespace, version, label) VALUES (?, ?, ?);`,
-		namespace, version, label)
+// openPostgresStore connects to the PostgreSQL database described by the DSN.
+func openPostgresStore(dsn string) (*sqlStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("database dsn must be set for the postgres driver")
+	}
+	db, err := sql.Open(postgresDialect.name, dsn)
 	if err != nil {
-		return fmt.Errorf("failed to add release history to database: %w", err)
+		return nil, fmt.Errorf("failed to open PostgreSQL database: %w", err)
 	}
-	return nil
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL database: %w", err)
+	}
+	return &sqlStore{db: db, dialect: postgresDialect}, nil
 }
 
-// Checks if the release_history table exists and has all required columns.
-func checkReleaseHistoryTable() error {
-	// Check if the release_history table exists
-	var tableExists int
-	queryTableExists := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='release_history';"
-	err := db.QueryRow(queryTableExists).Scan(&tableExists)
+// insert runs the INSERT query and returns the ID of the new row.
+func (s *sqlStore) insert(query string, args ...interface{}) (int64, error) {
+	if s.dialect.returningID {
+		var id int64
+		query = strings.TrimSuffix(strings.TrimSpace(query), ";") + " RETURNING id;"
+		err := s.db.QueryRow(s.dialect.rebind(query), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := s.db.Exec(s.dialect.rebind(query), args...)
 	if err != nil {
-		return fmt.Errorf("error checking for release_history table existence: %w", err)
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// dbTime normalizes a time that is stored or compared in a query. SQLite
+// compares DATETIME values as text, which only orders them correctly while
+// they all have the same format, so the fraction of a second, whose length
+// varies, is dropped.
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// isUniqueViolation reports whether the query failed on a unique constraint.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 	}
-	if tableExists == 0 {
-		return fmt.Errorf("release_history table does not exist")
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505" // unique_violation
 	}
+	return false
+}
 
-	// Check for the existence of required columns in the table
-	requiredColumns := []string{"namespace", "version", "label", "release_time"}
-	for _, column := range requiredColumns {
-		var columnExists int
-		queryColumnExists := fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info('release_history') WHERE name='%s';", column)
-		err := db.QueryRow(queryColumnExists).Scan(&columnExists)
-		if err != nil {
-			return fmt.Errorf("error checking for column %s existence: %w", column, err)
-		}
-		if columnExists == 0 {
-			return fmt.Errorf("column %s does not exist in release_history table", column)
+// Ensures the data directory exists, creates it if not.
+func ensureDataDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s directory: %w", dir, err)
 		}
 	}
+	return nil
+}
 
-	return nil // Return nil if the table exists with all required columns
+// Adds a new entry to the release_history table in the database.
+func (s *sqlStore) AddReleaseHistory(r Release) error {
+	_, err := s.db.Exec(s.dialect.rebind(`
+        INSERT INTO release_history (namespace, version, label, action, actor, details) VALUES (?, ?, ?, ?, ?, ?);`),
+		r.Namespace, r.Version, r.Label, r.Action, r.Actor, r.Details)
+	if err != nil {
+		return fmt.Errorf("failed to add release history to database: %w", err)
+	}
+	return nil
 }
 
 // Retrieves the version prior to the current version from the release_history table.
-func getPreviousVersion(namespace, currentVersion, label string) (string, error) {
+func (s *sqlStore) GetPreviousVersion(namespace, currentVersion, label string) (string, error) {
 	var previousVersion string
-	err := db.QueryRow(`
+	err := s.db.QueryRow(s.dialect.rebind(`
         SELECT version FROM release_history
-        WHERE namespace = ? AND version != ? AND label = ? AND id < (SELECT id FROM release_history WHERE version = ? AND label = ? ORDER BY id DESC LIMIT 1)
+        WHERE namespace = ? AND version != ? AND label = ? AND action IN (?, ?) AND id < (SELECT id FROM release_history WHERE namespace = ? AND version = ? AND label = ? AND action IN (?, ?) ORDER BY id DESC LIMIT 1)
         ORDER BY id DESC LIMIT 1
-    `, namespace, currentVersion, label, currentVersion, label).Scan(&previousVersion)
+    `), namespace, currentVersion, label, historyRelease, historyPromote, namespace, currentVersion, label, historyRelease, historyPromote).Scan(&previousVersion)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", nil // Return nil if no previous version is found
@@ -101,3 +284,262 @@ func getPreviousVersion(namespace, currentVersion, label string) (string, error)
 	}
 	return previousVersion, nil // Return the found previous version
 }
+
+// Lists the latest releases of the app in the namespace from the release_history table.
+func (s *sqlStore) ListReleaseHistory(namespace, label string, limit int) ([]Release, error) {
+	rows, err := s.db.Query(s.dialect.rebind(`
+        SELECT id, namespace, version, label, release_time, action, actor, details FROM release_history
+        WHERE namespace = ? AND label = ?
+        ORDER BY id DESC LIMIT ?
+    `), namespace, label, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release history from database: %w", err)
+	}
+	defer rows.Close()
+
+	var releases []Release
+	for rows.Next() {
+		var r Release
+		if err := rows.Scan(&r.ID, &r.Namespace, &r.Version, &r.Label, &r.ReleaseTime, &r.Action, &r.Actor, &r.Details); err != nil {
+			return nil, fmt.Errorf("failed to read release history row: %w", err)
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// Closes the database connection.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// approvalColumns lists the approval_requests columns in the order scanApprovalRequest reads them.
+const approvalColumns = "id, environment, label, version, requested_by, channel_id, message_ts, status, force, decided_by, created_at, expires_at, decided_at"
+
+// scanApprovalRequest reads an approval_requests row selected with approvalColumns.
+func scanApprovalRequest(row interface{ Scan(...interface{}) error }) (*ApprovalRequest, error) {
+	var r ApprovalRequest
+	var decidedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.Environment, &r.Label, &r.Version, &r.RequestedBy, &r.ChannelID, &r.MessageTS, &r.Status, &r.Force, &r.DecidedBy, &r.CreatedAt, &r.ExpiresAt, &decidedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.DecidedAt = decidedAt.Time
+	return &r, nil
+}
+
+// Adds a new pending approval request and sets its ID.
+func (s *sqlStore) CreateApprovalRequest(r *ApprovalRequest) error {
+	id, err := s.insert(`
+        INSERT INTO approval_requests (environment, label, version, requested_by, channel_id, status, force, created_at, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		r.Environment, r.Label, r.Version, r.RequestedBy, r.ChannelID, approvalPending, r.Force, dbTime(r.CreatedAt), dbTime(r.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to add approval request to database: %w", err)
+	}
+	r.ID = id
+	r.Status = approvalPending
+	return nil
+}
+
+// Stores the Slack message that shows the approval request.
+func (s *sqlStore) SetApprovalMessage(id int64, channelID, messageTS string) error {
+	_, err := s.db.Exec(s.dialect.rebind(`
+        UPDATE approval_requests SET channel_id = ?, message_ts = ? WHERE id = ?;`),
+		channelID, messageTS, id)
+	if err != nil {
+		return fmt.Errorf("failed to update approval request message: %w", err)
+	}
+	return nil
+}
+
+// Retrieves the approval request with the given ID, or nil if there is none.
+func (s *sqlStore) GetApprovalRequest(id int64) (*ApprovalRequest, error) {
+	r, err := scanApprovalRequest(s.db.QueryRow(s.dialect.rebind(
+		"SELECT "+approvalColumns+" FROM approval_requests WHERE id = ?;"), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get approval request from database: %w", err)
+	}
+	return r, nil
+}
+
+// Moves a pending approval request to the given status. It reports false if the
+// request was no longer pending, e.g. because another user decided it first.
+func (s *sqlStore) DecideApprovalRequest(id int64, status, decidedBy string) (bool, error) {
+	result, err := s.db.Exec(s.dialect.rebind(`
+        UPDATE approval_requests SET status = ?, decided_by = ?, decided_at = ?
+        WHERE id = ? AND status = ?;`),
+		status, decidedBy, dbTime(time.Now()), id, approvalPending)
+	if err != nil {
+		return false, fmt.Errorf("failed to update approval request: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update approval request: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// Lists the pending approval requests that expired before now.
+func (s *sqlStore) ListExpiredApprovalRequests(now time.Time) ([]ApprovalRequest, error) {
+	rows, err := s.db.Query(s.dialect.rebind(
+		"SELECT "+approvalColumns+" FROM approval_requests WHERE status = ? AND expires_at < ? ORDER BY id;"),
+		approvalPending, dbTime(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired approval requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []ApprovalRequest
+	for rows.Next() {
+		r, err := scanApprovalRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read approval request row: %w", err)
+		}
+		requests = append(requests, *r)
+	}
+	return requests, rows.Err()
+}
+
+// freezeColumns lists the environment_freezes columns in the order scanFreeze reads them.
+const freezeColumns = "id, environment, reason, frozen_by, channel_id, suspended, created_at, expires_at, lifted_by, lifted_at"
+
+// scanFreeze reads an environment_freezes row selected with freezeColumns.
+func scanFreeze(row interface{ Scan(...interface{}) error }) (*Freeze, error) {
+	var f Freeze
+	var suspended string
+	var expiresAt, liftedAt sql.NullTime
+	err := row.Scan(&f.ID, &f.Environment, &f.Reason, &f.FrozenBy, &f.ChannelID, &suspended, &f.CreatedAt, &expiresAt, &f.LiftedBy, &liftedAt)
+	if err != nil {
+		return nil, err
+	}
+	if suspended != "" {
+		f.Suspended = strings.Split(suspended, ",")
+	}
+	f.ExpiresAt, f.LiftedAt = expiresAt.Time, liftedAt.Time
+	return &f, nil
+}
+
+// Adds a new active freeze of an environment and sets its ID. The unique index
+// on the environment of freezes that are not lifted rejects concurrent freezes.
+func (s *sqlStore) CreateFreeze(f *Freeze) error {
+	var expiresAt sql.NullTime
+	if !f.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: dbTime(f.ExpiresAt), Valid: true}
+	}
+	id, err := s.insert(`
+        INSERT INTO environment_freezes (environment, reason, frozen_by, channel_id, suspended, created_at, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);`,
+		f.Environment, f.Reason, f.FrozenBy, f.ChannelID, strings.Join(f.Suspended, ","), dbTime(f.CreatedAt), expiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errAlreadyFrozen
+		}
+		return fmt.Errorf("failed to add freeze to database: %w", err)
+	}
+	f.ID = id
+	return nil
+}
+
+// Records the Flux objects the freeze suspended.
+func (s *sqlStore) SetFreezeSuspended(id int64, suspended []string) error {
+	_, err := s.db.Exec(s.dialect.rebind("UPDATE environment_freezes SET suspended = ? WHERE id = ?;"), strings.Join(suspended, ","), id)
+	if err != nil {
+		return fmt.Errorf("failed to update freeze: %w", err)
+	}
+	return nil
+}
+
+// Retrieves the latest freeze of the environment that is neither lifted nor expired, or nil if there is none.
+func (s *sqlStore) GetActiveFreeze(environment string, now time.Time) (*Freeze, error) {
+	f, err := scanFreeze(s.db.QueryRow(s.dialect.rebind(
+		"SELECT "+freezeColumns+" FROM environment_freezes WHERE environment = ? AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?) ORDER BY id DESC LIMIT 1;"),
+		environment, dbTime(now)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get freeze from database: %w", err)
+	}
+	return f, nil
+}
+
+// Ends the freeze. It reports false if the freeze was already lifted, e.g. by another replica.
+func (s *sqlStore) LiftFreeze(id int64, liftedBy string) (bool, error) {
+	result, err := s.db.Exec(s.dialect.rebind(`
+        UPDATE environment_freezes SET lifted_by = ?, lifted_at = ?
+        WHERE id = ? AND lifted_at IS NULL;`),
+		liftedBy, dbTime(time.Now()), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to lift freeze: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to lift freeze: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// Lists the freezes that expired before now and have not been lifted yet.
+func (s *sqlStore) ListExpiredFreezes(now time.Time) ([]Freeze, error) {
+	rows, err := s.db.Query(s.dialect.rebind(
+		"SELECT "+freezeColumns+" FROM environment_freezes WHERE lifted_at IS NULL AND expires_at IS NOT NULL AND expires_at <= ? ORDER BY id;"),
+		dbTime(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired freezes: %w", err)
+	}
+	defer rows.Close()
+
+	var freezes []Freeze
+	for rows.Next() {
+		f, err := scanFreeze(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read freeze row: %w", err)
+		}
+		freezes = append(freezes, *f)
+	}
+	return freezes, rows.Err()
+}
+
+// Retrieves the drift of the app in the environment, or nil if there is none.
+func (s *sqlStore) GetDrift(environment, label string) (*Drift, error) {
+	var d Drift
+	err := s.db.QueryRow(s.dialect.rebind(`
+        SELECT environment, label, desired_version, running_version, detected_at, reported
+        FROM drifts WHERE environment = ? AND label = ?;`),
+		environment, label).Scan(&d.Environment, &d.Label, &d.Desired, &d.Running, &d.DetectedAt, &d.Reported)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get drift from database: %w", err)
+	}
+	return &d, nil
+}
+
+// Adds the drift of the app in the environment, replacing the one stored before.
+func (s *sqlStore) SaveDrift(d *Drift) error {
+	_, err := s.db.Exec(s.dialect.rebind(`
+        INSERT INTO drifts (environment, label, desired_version, running_version, detected_at, reported)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (environment, label) DO UPDATE SET
+            desired_version = excluded.desired_version, running_version = excluded.running_version,
+            detected_at = excluded.detected_at, reported = excluded.reported;`),
+		d.Environment, d.Label, d.Desired, d.Running, d.DetectedAt.UTC(), d.Reported)
+	if err != nil {
+		return fmt.Errorf("failed to save drift to database: %w", err)
+	}
+	return nil
+}
+
+// Removes the drift of the app in the environment.
+func (s *sqlStore) DeleteDrift(environment, label string) error {
+	_, err := s.db.Exec(s.dialect.rebind("DELETE FROM drifts WHERE environment = ? AND label = ?;"), environment, label)
+	if err != nil {
+		return fmt.Errorf("failed to delete drift from database: %w", err)
+	}
+	return nil
+}
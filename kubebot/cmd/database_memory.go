@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryStore is a Store that keeps everything in memory. It is meant for
+// tests and local experiments, as nothing survives a restart.
+type memoryStore struct {
+	mu        sync.Mutex
+	releases  []Release
+	approvals []ApprovalRequest
+	freezes   []Freeze
+	drifts    map[string]Drift
+}
+
+// newMemoryStore returns an empty in-memory store.
+func newMemoryStore() *memoryStore {
+	return &memoryStore{drifts: make(map[string]Drift)}
+}
+
+// Adds a new entry to the in-memory release history.
+func (s *memoryStore) AddReleaseHistory(r Release) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = int64(len(s.releases) + 1)
+	r.ReleaseTime = time.Now().UTC()
+	s.releases = append(s.releases, r)
+	return nil
+}
+
+// Retrieves the version released before the latest release of the current version, counting only releases and promotions.
+func (s *memoryStore) GetPreviousVersion(namespace, currentVersion, label string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Find the latest release of the current version
+	current := -1
+	for i := len(s.releases) - 1; i >= 0; i-- {
+		r := s.releases[i]
+		if r.Namespace == namespace && r.Label == label && r.Version == currentVersion && deploysVersion(r.Action) {
+			current = i
+			break
+		}
+	}
+
+	// Walk back to the closest release of a different version
+	for i := current - 1; i >= 0; i-- {
+		r := s.releases[i]
+		if r.Namespace == namespace && r.Label == label && r.Version != currentVersion && deploysVersion(r.Action) {
+			return r.Version, nil
+		}
+	}
+	return "", nil
+}
+
+// Lists the latest releases of the app in the namespace, newest first.
+func (s *memoryStore) ListReleaseHistory(namespace, label string, limit int) ([]Release, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var releases []Release
+	for i := len(s.releases) - 1; i >= 0 && len(releases) < limit; i-- {
+		r := s.releases[i]
+		if r.Namespace == namespace && r.Label == label {
+			releases = append(releases, r)
+		}
+	}
+	return releases, nil
+}
+
+// The in-memory store has no schema to migrate.
+func (s *memoryStore) Migrate() error {
+	return nil
+}
+
+// The in-memory store has no schema migrations.
+func (s *memoryStore) MigrationStatus() ([]MigrationState, error) {
+	return nil, nil
+}
+
+// The in-memory store holds no resources.
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+// Adds a new pending approval request and sets its ID.
+func (s *memoryStore) CreateApprovalRequest(r *ApprovalRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = int64(len(s.approvals) + 1)
+	r.Status = approvalPending
+	s.approvals = append(s.approvals, *r)
+	return nil
+}
+
+// Stores the Slack message that shows the approval request.
+func (s *memoryStore) SetApprovalMessage(id int64, channelID, messageTS string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r := s.approval(id); r != nil {
+		r.ChannelID, r.MessageTS = channelID, messageTS
+	}
+	return nil
+}
+
+// Retrieves the approval request with the given ID, or nil if there is none.
+func (s *memoryStore) GetApprovalRequest(id int64) (*ApprovalRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.approval(id)
+	if r == nil {
+		return nil, nil
+	}
+	copied := *r
+	return &copied, nil
+}
+
+// Moves a pending approval request to the given status, reporting false if it was no longer pending.
+func (s *memoryStore) DecideApprovalRequest(id int64, status, decidedBy string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.approval(id)
+	if r == nil || r.Status != approvalPending {
+		return false, nil
+	}
+	r.Status, r.DecidedBy, r.DecidedAt = status, decidedBy, time.Now().UTC()
+	return true, nil
+}
+
+// Lists the pending approval requests that expired before now.
+func (s *memoryStore) ListExpiredApprovalRequests(now time.Time) ([]ApprovalRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []ApprovalRequest
+	for _, r := range s.approvals {
+		if r.Status == approvalPending && r.ExpiresAt.Before(now) {
+			requests = append(requests, r)
+		}
+	}
+	return requests, nil
+}
+
+// approval returns the stored approval request with the given ID. The caller must hold s.mu.
+func (s *memoryStore) approval(id int64) *ApprovalRequest {
+	if id < 1 || id > int64(len(s.approvals)) {
+		return nil
+	}
+	return &s.approvals[id-1]
+}
+
+// Adds a new active freeze of an environment and sets its ID, unless the environment has a freeze that is not lifted.
+func (s *memoryStore) CreateFreeze(f *Freeze) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.freezes {
+		if existing.Environment == f.Environment && existing.LiftedAt.IsZero() {
+			return errAlreadyFrozen
+		}
+	}
+	f.ID = int64(len(s.freezes) + 1)
+	s.freezes = append(s.freezes, *f)
+	return nil
+}
+
+// Records the Flux objects the freeze suspended.
+func (s *memoryStore) SetFreezeSuspended(id int64, suspended []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id >= 1 && id <= int64(len(s.freezes)) {
+		s.freezes[id-1].Suspended = suspended
+	}
+	return nil
+}
+
+// Retrieves the latest freeze of the environment that is neither lifted nor expired, or nil if there is none.
+func (s *memoryStore) GetActiveFreeze(environment string, now time.Time) (*Freeze, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.freezes) - 1; i >= 0; i-- {
+		f := s.freezes[i]
+		if f.Environment == environment && f.LiftedAt.IsZero() && (f.ExpiresAt.IsZero() || f.ExpiresAt.After(now)) {
+			return &f, nil
+		}
+	}
+	return nil, nil
+}
+
+// Ends the freeze, reporting false if it was already lifted.
+func (s *memoryStore) LiftFreeze(id int64, liftedBy string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.freezes)) || !s.freezes[id-1].LiftedAt.IsZero() {
+		return false, nil
+	}
+	s.freezes[id-1].LiftedBy, s.freezes[id-1].LiftedAt = liftedBy, time.Now().UTC()
+	return true, nil
+}
+
+// Lists the freezes that expired before now and have not been lifted yet.
+func (s *memoryStore) ListExpiredFreezes(now time.Time) ([]Freeze, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var freezes []Freeze
+	for _, f := range s.freezes {
+		if f.LiftedAt.IsZero() && !f.ExpiresAt.IsZero() && !f.ExpiresAt.After(now) {
+			freezes = append(freezes, f)
+		}
+	}
+	return freezes, nil
+}
+
+// Retrieves the drift of the app in the environment, or nil if there is none.
+func (s *memoryStore) GetDrift(environment, label string) (*Drift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.drifts[environment+"/"+label]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// Adds the drift of the app in the environment, replacing the one stored before.
+func (s *memoryStore) SaveDrift(d *Drift) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drifts[d.Environment+"/"+d.Label] = *d
+	return nil
+}
+
+// Removes the drift of the app in the environment.
+func (s *memoryStore) DeleteDrift(environment, label string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.drifts, environment+"/"+label)
+	return nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testStores returns an empty in-memory store and an empty migrated SQLite
+// store, so that the tests check both implement the same contract.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	sqlite, err := openSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	if err := sqlite.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Store{"memory": newMemoryStore(), "sqlite": sqlite}
+}
+
+func TestGetPreviousVersion(t *testing.T) {
+	history := []Release{
+		{Namespace: "prod", Label: "kbot", Version: "v1", Action: historyPromote},
+		{Namespace: "prod", Label: "kbot", Version: "v2", Action: historyPromote},
+		{Namespace: "qa", Label: "kbot", Version: "v9", Action: historyPromote},
+		{Namespace: "prod", Label: "other", Version: "v8", Action: historyPromote},
+		{Namespace: "prod", Label: "kbot", Version: "v3", Action: historyPromote},
+		{Namespace: "prod", Label: "kbot", Version: "v2", Action: historyRollback},
+		{Namespace: "prod", Label: "kbot", Version: "v2", Action: historyRestart},
+		{Namespace: "prod", Label: "kbot", Version: "v2", Action: historyScale},
+	}
+	tests := []struct {
+		name    string
+		current string
+		want    string
+	}{
+		{name: "latest release", current: "v3", want: "v2"},
+		{name: "rolled back version skips the rollback, restart and scaling", current: "v2", want: "v1"},
+		{name: "first release", current: "v1", want: ""},
+		{name: "unknown version", current: "v7", want: ""},
+	}
+
+	for storeName, store := range testStores(t) {
+		for _, r := range history {
+			if err := store.AddReleaseHistory(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				got, err := store.GetPreviousVersion("prod", tt.current, "kbot")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("GetPreviousVersion(%s) = %q, want %q", tt.current, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecideApprovalRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		decisions  []string
+		wantFirst  bool
+		wantSecond bool
+		wantStatus string
+	}{
+		{name: "pending request", decisions: []string{approvalApproved}, wantFirst: true, wantStatus: approvalApproved},
+		{name: "already approved", decisions: []string{approvalApproved, approvalRejected}, wantFirst: true, wantSecond: false, wantStatus: approvalApproved},
+		{name: "already expired", decisions: []string{approvalExpired, approvalApproved}, wantFirst: true, wantSecond: false, wantStatus: approvalExpired},
+	}
+
+	for storeName, store := range testStores(t) {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				now := time.Now()
+				request := &ApprovalRequest{Environment: "prod", Label: "kbot", Version: "v2", RequestedBy: "U1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+				if err := store.CreateApprovalRequest(request); err != nil {
+					t.Fatal(err)
+				}
+
+				want := []bool{tt.wantFirst, tt.wantSecond}
+				for i, status := range tt.decisions {
+					decided, err := store.DecideApprovalRequest(request.ID, status, "U2")
+					if err != nil {
+						t.Fatal(err)
+					}
+					if decided != want[i] {
+						t.Errorf("decision %d to %s = %v, want %v", i+1, status, decided, want[i])
+					}
+				}
+
+				got, err := store.GetApprovalRequest(request.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Status != tt.wantStatus {
+					t.Errorf("status = %s, want %s", got.Status, tt.wantStatus)
+				}
+			})
+		}
+	}
+}
+
+func TestListExpiredApprovalRequests(t *testing.T) {
+	// Expiry times with fractions of a second of different lengths, which SQLite compares as text
+	expiresAt := time.Date(2024, 5, 1, 10, 0, 0, 500_000_000, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{name: "a second before", now: expiresAt.Add(-time.Second), want: 0},
+		{name: "a second after", now: expiresAt.Add(time.Second), want: 1},
+		{name: "an hour after", now: expiresAt.Add(time.Hour), want: 1},
+	}
+
+	for storeName, store := range testStores(t) {
+		request := &ApprovalRequest{Environment: "prod", Label: "kbot", Version: "v2", RequestedBy: "U1", CreatedAt: expiresAt.Add(-time.Hour), ExpiresAt: expiresAt}
+		if err := store.CreateApprovalRequest(request); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				expired, err := store.ListExpiredApprovalRequests(tt.now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(expired) != tt.want {
+					t.Errorf("ListExpiredApprovalRequests(%s) returned %d requests, want %d", tt.now, len(expired), tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestCreateFreeze(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			now := time.Now()
+			first := &Freeze{Environment: "prod", FrozenBy: "U1", CreatedAt: now}
+			if err := store.CreateFreeze(first); err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				name   string
+				freeze *Freeze
+				want   error
+			}{
+				{name: "same environment", freeze: &Freeze{Environment: "prod", FrozenBy: "U2", CreatedAt: now}, want: errAlreadyFrozen},
+				{name: "other environment", freeze: &Freeze{Environment: "qa", FrozenBy: "U2", CreatedAt: now}, want: nil},
+			}
+			for _, tt := range tests {
+				if err := store.CreateFreeze(tt.freeze); err != tt.want {
+					t.Errorf("%s: CreateFreeze() = %v, want %v", tt.name, err, tt.want)
+				}
+			}
+
+			if lifted, err := store.LiftFreeze(first.ID, "U1"); err != nil || !lifted {
+				t.Fatalf("LiftFreeze() = %v, %v", lifted, err)
+			}
+			if err := store.CreateFreeze(&Freeze{Environment: "prod", FrozenBy: "U2", CreatedAt: now}); err != nil {
+				t.Errorf("CreateFreeze() after lifting = %v", err)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect sqlDialect
+		query   string
+		want    string
+	}{
+		{name: "sqlite keeps placeholders", dialect: sqliteDialect, query: "SELECT * FROM t WHERE a = ? AND b = ?;", want: "SELECT * FROM t WHERE a = ? AND b = ?;"},
+		{name: "postgres numbers placeholders", dialect: postgresDialect, query: "SELECT * FROM t WHERE a = ? AND b = ?;", want: "SELECT * FROM t WHERE a = $1 AND b = $2;"},
+		{name: "postgres without placeholders", dialect: postgresDialect, query: "SELECT 1;", want: "SELECT 1;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateStatus is set by --status to list migrations instead of applying them.
+var migrateStatus bool
+
+// dbCmd groups the commands that maintain the release history database.
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the release history database",
+	Long:  `Commands to maintain the release history database configured in the database section of the config.`,
+}
+
+// dbMigrateCmd applies the pending schema migrations, or lists them with --status.
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long:  `Applies the pending schema migrations to the release history database. With --status it only lists the migrations and whether they have been applied.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore(cfg.Database)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		if !migrateStatus {
+			if err := s.Migrate(); err != nil {
+				return err
+			}
+			fmt.Println("Database schema is up to date")
+			return nil
+		}
+
+		states, err := s.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, state := range states {
+			status := "pending"
+			if state.Applied {
+				status = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", state.Version, state.Name, status)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	dbMigrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "list migrations and whether they have been applied, without applying them")
+	dbCmd.AddCommand(dbMigrateCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxMessageBlocks is the most blocks Slack accepts in a single message.
+const maxMessageBlocks = 50
+
+// handleDescribeCommand posts the details of a pod, or of every pod of an app,
+// as Block Kit sections.
+func handleDescribeCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/describe").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) != 2 {
+		totalErrors.WithLabelValues("/describe").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /describe <namespace> <pod|label>")
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("describe") {
+		totalErrors.WithLabelValues("/describe").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for describing pods. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("describe")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "describe"); err != nil {
+		totalErrors.WithLabelValues("/describe").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	namespace := env.Namespace
+
+	// A pod name takes precedence, otherwise the argument is the label of an app
+	var pods []*corev1.Pod
+	if pod, err := getPod(namespace, parts[1]); err == nil {
+		pods = append(pods, pod)
+	} else {
+		pods, err = listPods(namespace, appSelector(parts[1]))
+		if err != nil {
+			totalErrors.WithLabelValues("/describe").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to list pods in namespace `%s`: %s", namespace, err))
+		}
+	}
+	if len(pods) == 0 {
+		totalErrors.WithLabelValues("/describe").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("No pod named `%s` or with label `%s` found in namespace `%s`.", parts[1], parts[1], namespace))
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	blocks := []slack.Block{
+		slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Command:* `%s` by <@%s>", commandText, command.UserID), false, false)),
+	}
+	now := time.Now()
+	for i, pod := range pods {
+		described := podBlocks(pod, now)
+		// Keep room for the divider and the note about the pods left out
+		if len(blocks)+len(described)+2 > maxMessageBlocks {
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("…and %d more pods", len(pods)-i), false, false)))
+			break
+		}
+		blocks = append(blocks, slack.NewDividerBlock())
+		blocks = append(blocks, described...)
+	}
+
+	if _, _, err := client.PostMessage(command.ChannelID, slack.MsgOptionBlocks(blocks...)); err != nil {
+		return nil, fmt.Errorf("failed to post pod description: %w", err)
+	}
+	return nil, nil
+}
+
+// podBlocks renders a pod: a section with its status, node and age, a context
+// line with its conditions, and a section per container.
+func podBlocks(pod *corev1.Pod, now time.Time) []slack.Block {
+	ready, restarts := 0, int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+
+	fields := []*slack.TextBlockObject{
+		markdownField("Status", podStatus(pod)),
+		markdownField("Ready", fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))),
+		markdownField("Restarts", fmt.Sprintf("%d", restarts)),
+		markdownField("Age", formatAge(now.Sub(pod.CreationTimestamp.Time))),
+		markdownField("Node", valueOrNone(pod.Spec.NodeName)),
+		markdownField("Pod IP", valueOrNone(pod.Status.PodIP)),
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Pod* `%s`", pod.Name), false, false), fields, nil),
+	}
+
+	var conditions []string
+	for _, condition := range pod.Status.Conditions {
+		text := fmt.Sprintf("%s=%s", condition.Type, condition.Status)
+		if condition.Status != corev1.ConditionTrue && condition.Reason != "" {
+			text += fmt.Sprintf(" (%s)", condition.Reason)
+		}
+		conditions = append(conditions, text)
+	}
+	if len(conditions) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, "*Conditions:* "+strings.Join(conditions, ", "), false, false)))
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range pod.Status.InitContainerStatuses {
+		statuses["init/"+status.Name] = status
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+	for _, container := range pod.Spec.InitContainers {
+		blocks = append(blocks, containerBlock(container, statuses["init/"+container.Name], "Init container"))
+	}
+	for _, container := range pod.Spec.Containers {
+		blocks = append(blocks, containerBlock(container, statuses[container.Name], "Container"))
+	}
+	return blocks
+}
+
+// containerBlock renders the image, state, restarts, last termination and
+// resources of a container.
+func containerBlock(container corev1.Container, status corev1.ContainerStatus, kind string) slack.Block {
+	state := "Unknown"
+	switch {
+	case status.State.Running != nil:
+		state = "Running"
+	case status.State.Waiting != nil:
+		state = "Waiting: " + status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		state = fmt.Sprintf("Terminated: %s (exit code %d)", status.State.Terminated.Reason, status.State.Terminated.ExitCode)
+	}
+
+	lastTermination := "None"
+	if terminated := status.LastTerminationState.Terminated; terminated != nil {
+		lastTermination = fmt.Sprintf("%s (exit code %d) at %s", terminated.Reason, terminated.ExitCode, terminated.FinishedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	fields := []*slack.TextBlockObject{
+		markdownField("Image", "`"+container.Image+"`"),
+		markdownField("Image ID", "`"+valueOrNone(status.ImageID)+"`"),
+		markdownField("State", state),
+		markdownField("Restarts", fmt.Sprintf("%d", status.RestartCount)),
+		markdownField("Last termination", lastTermination),
+		markdownField("Ready", fmt.Sprintf("%t", status.Ready)),
+		markdownField("Requests", formatResources(container.Resources.Requests)),
+		markdownField("Limits", formatResources(container.Resources.Limits)),
+	}
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*%s* `%s`", kind, container.Name), false, false), fields, nil)
+}
+
+// formatResources renders a resource list as "cpu=100m, memory=128Mi".
+func formatResources(resources corev1.ResourceList) string {
+	if len(resources) == 0 {
+		return "None"
+	}
+	var values []string
+	for name, quantity := range resources {
+		values = append(values, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ", ")
+}
+
+// markdownField returns a section field with a bold title and a value.
+func markdownField(title, value string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*%s*\n%s", title, value), false, false)
+}
+
+// valueOrNone returns value, or "None" if it is empty.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "None"
+	}
+	return value
+}
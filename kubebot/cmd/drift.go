@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// unpinnedRangeChars appear in ImagePolicy ranges that select versions instead
+// of pinning one, which running pods cannot be compared with.
+const unpinnedRangeChars = " <>=~^*|,"
+
+// Drift is a difference between the version the GitOps repository pins for an
+// app in an environment and the versions its pods run.
+type Drift struct {
+	Environment string
+	Label       string
+	Desired     string
+	// Running lists the distinct versions the pods run, comma separated, empty if no pods run.
+	Running string
+	// DetectedAt is when this drift was first observed.
+	DetectedAt time.Time
+	// Reported is true once the periodic check posted the drift.
+	Reported bool
+}
+
+// DriftStore persists the drifts that are currently observed, so that how long
+// they have lasted survives restarts.
+type DriftStore interface {
+	// GetDrift returns the drift of the app in the environment, or nil if there is none.
+	GetDrift(environment, label string) (*Drift, error)
+	// SaveDrift adds or replaces the drift of the app in the environment.
+	SaveDrift(d *Drift) error
+	// DeleteDrift removes the drift of the app in the environment once it is resolved.
+	DeleteDrift(environment, label string) error
+}
+
+// DriftConfig describes the periodic drift check.
+type DriftConfig struct {
+	// Channel is the Slack channel ID drifts are posted to. The periodic check is disabled when it is empty.
+	Channel string `yaml:"channel"`
+	// Interval is how often the versions are compared. Defaults to 10 minutes.
+	Interval time.Duration `yaml:"interval"`
+	// Threshold is how long a drift must last before it is posted, so that
+	// rollouts in progress are not reported. Defaults to 15 minutes.
+	Threshold time.Duration `yaml:"threshold"`
+}
+
+// driftCheck is the outcome of comparing the desired and running versions of an app.
+type driftCheck struct {
+	env     *Environment
+	app     *App
+	desired string
+	running []string
+	// unpinned is true when the ImagePolicy range selects versions instead of pinning one.
+	unpinned bool
+	// drift is the persisted drift while the versions differ.
+	drift *Drift
+	// resolved is the drift that this check found to be gone.
+	resolved *Drift
+	err      error
+}
+
+// checkDrift compares the version the GitOps repository pins for the app in
+// the environment with the versions its pods run, and records when a drift
+// was first observed. A drift lasts, keeping when it started and whether it
+// was reported, until the pods run the pinned version.
+func checkDrift(ctx context.Context, app *App, env *Environment, now time.Time) driftCheck {
+	check := driftCheck{env: env, app: app}
+
+	check.desired, check.err = desiredVersion(ctx, app, env)
+	if check.err != nil {
+		return check
+	}
+	if strings.ContainsAny(check.desired, unpinnedRangeChars) {
+		check.unpinned = true
+		return check
+	}
+
+	pods, err := listPods(env.Namespace, appSelector(app.Name))
+	if err != nil {
+		check.err = fmt.Errorf("failed to list pods in namespace %s: %w", env.Namespace, err)
+		return check
+	}
+	seen := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if version, err := appVersion(pod.Spec, app.Name); err == nil && !seen[version] {
+			seen[version] = true
+			check.running = append(check.running, version)
+		}
+	}
+	sort.Strings(check.running)
+
+	existing, err := store.GetDrift(env.Name, app.Name)
+	if err != nil {
+		check.err = err
+		return check
+	}
+
+	if len(check.running) == 1 && check.running[0] == check.desired {
+		if existing != nil {
+			if err := store.DeleteDrift(env.Name, app.Name); err != nil {
+				check.err = err
+				return check
+			}
+			check.resolved = existing
+		}
+		return check
+	}
+
+	running := strings.Join(check.running, ",")
+	if existing == nil {
+		existing = &Drift{Environment: env.Name, Label: app.Name, DetectedAt: now}
+	} else if existing.Desired == check.desired && existing.Running == running {
+		check.drift = existing
+		return check
+	}
+	// The versions may change while the app stays drifted, e.g. by another
+	// promotion; the drift still dates from when it was first observed
+	existing.Desired, existing.Running = check.desired, running
+	check.drift = existing
+	if err := store.SaveDrift(check.drift); err != nil {
+		check.err = err
+	}
+	return check
+}
+
+// String renders the result of the check on a single line.
+func (c driftCheck) String() string {
+	prefix := fmt.Sprintf("`%s` in `%s`", c.app.Name, c.env.Name)
+	switch {
+	case c.err != nil:
+		return fmt.Sprintf(":x: %s: failed to check: %s", prefix, c.err)
+	case c.unpinned:
+		return fmt.Sprintf(":grey_question: %s: git selects versions by the range `%s` instead of pinning one", prefix, c.desired)
+	case c.drift != nil:
+		running := "no pods"
+		if len(c.running) > 0 {
+			running = "pods run `" + strings.Join(c.running, "`, `") + "`"
+		}
+		return fmt.Sprintf(":warning: %s: git wants `%s`, %s, for %s", prefix, c.desired, running, formatAge(time.Since(c.drift.DetectedAt)))
+	}
+	return fmt.Sprintf(":white_check_mark: %s: `%s` as in git", prefix, c.desired)
+}
+
+// handleDriftCommand compares the versions pinned in the GitOps repository with
+// the versions the pods run, in one environment or in every one that allows it.
+func handleDriftCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/drift").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) > 1 {
+		totalErrors.WithLabelValues("/drift").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /drift [namespace]")
+	}
+
+	envs := botConfig.environmentsAllowing("drift")
+	if len(parts) == 1 {
+		env := botConfig.environment(parts[0])
+		if env == nil || !env.allows("drift") {
+			totalErrors.WithLabelValues("/drift").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for drift detection. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("drift")))
+		}
+		envs = []*Environment{env}
+	}
+
+	var lines []string
+	for _, env := range envs {
+		if err := botConfig.authorize(command.UserID, env, "drift"); err != nil {
+			if len(parts) == 1 {
+				totalErrors.WithLabelValues("/drift").Inc()
+				return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+			}
+			continue
+		}
+		for i := range botConfig.Apps {
+			lines = append(lines, checkDrift(context.TODO(), &botConfig.Apps[i], env, time.Now()).String())
+		}
+	}
+	if len(lines) == 0 {
+		return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, "No apps to check. Register apps in the `apps` section of the config.")
+	}
+
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Versions in git compared with the running pods:\n%s", strings.Join(lines, "\n")))
+}
+
+// startDriftDetection periodically compares the versions pinned in the GitOps
+// repository with the running ones in the environments that allow /drift. A
+// drift is posted once it has lasted for the threshold, and again when it is resolved.
+func startDriftDetection(ctx context.Context, client *slack.Client) {
+	cfg := botConfig.Drift
+	if cfg.Channel == "" {
+		log.Println("Drift detection is disabled: drift.channel is not set")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, env := range botConfig.environmentsAllowing("drift") {
+				for i := range botConfig.Apps {
+					now := time.Now()
+					check := checkDrift(ctx, &botConfig.Apps[i], env, now)
+					switch {
+					case check.err != nil:
+						log.Printf("Failed to check drift of %s in %s: %v", check.app.Name, env.Name, check.err)
+					case check.resolved != nil && check.resolved.Reported:
+						postDriftMessage(client, cfg.Channel, fmt.Sprintf(":white_check_mark: `%s` in `%s` runs `%s` as in git again, after drifting for %s.", check.app.Name, env.Name, check.desired, formatAge(now.Sub(check.resolved.DetectedAt))))
+					case check.drift != nil && !check.drift.Reported && now.Sub(check.drift.DetectedAt) >= cfg.Threshold:
+						check.drift.Reported = true
+						if err := store.SaveDrift(check.drift); err != nil {
+							log.Printf("Failed to record the report of the drift of %s in %s: %v", check.app.Name, env.Name, err)
+							continue
+						}
+						postDriftMessage(client, cfg.Channel, check.String())
+					}
+				}
+			}
+		}
+	}
+}
+
+// postDriftMessage posts a drift or its resolution to the drift channel.
+func postDriftMessage(client *slack.Client, channelID, text string) {
+	if _, _, err := client.PostMessage(channelID, slack.MsgOptionText(text, false)); err != nil {
+		log.Printf("Failed to post drift message: %v", err)
+	}
+}
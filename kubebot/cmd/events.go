@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Limits of the /events message.
+const (
+	maxEventGroups        = 20
+	maxEventGroupObjects  = 3
+	maxEventMessageLength = 200 // in runes
+)
+
+// eventGroup is a set of events with the same type, reason and message.
+type eventGroup struct {
+	eventType string
+	reason    string
+	message   string
+	count     int32
+	firstSeen time.Time
+	lastSeen  time.Time
+	objects   []string
+}
+
+// handleEventsCommand posts the recent events of the Deployment, ReplicaSets
+// and pods of an app, grouped so that repeated events are shown once.
+func handleEventsCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/events").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) != 2 {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /events <namespace> <label>")
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("events") {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for listing events. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("events")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "events"); err != nil {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	label := parts[1]
+	namespace := env.Namespace
+
+	objects, err := appObjects(namespace, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to find objects of `%s` in namespace `%s`: %s", label, namespace, err))
+	}
+	if len(objects) == 0 {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("No deployment or pods with label `%s` found in namespace `%s`.", label, namespace))
+	}
+
+	events, err := clientset.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		totalErrors.WithLabelValues("/events").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to list events in namespace `%s`: %s", namespace, err))
+	}
+
+	var appEvents []corev1.Event
+	for _, event := range events.Items {
+		if objects[event.InvolvedObject.Kind+"/"+event.InvolvedObject.Name] {
+			appEvents = append(appEvents, event)
+		}
+	}
+	if len(appEvents) == 0 {
+		return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("No recent events for `%s` in namespace `%s`.", label, namespace))
+	}
+
+	groups := groupEvents(appEvents)
+	now := time.Now()
+	var messages []string
+	for i, group := range groups {
+		if i == maxEventGroups {
+			messages = append(messages, fmt.Sprintf("…and %d more", len(groups)-maxEventGroups))
+			break
+		}
+		messages = append(messages, formatEventGroup(group, now))
+	}
+
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Events of `%s` in namespace `%s`:\n%s", label, namespace, strings.Join(messages, "\n")))
+}
+
+// appObjects returns the Deployment, ReplicaSets and pods of an app as a set
+// of "Kind/name" keys matching the involved objects of events.
+func appObjects(namespace, label string) (map[string]bool, error) {
+	objects := make(map[string]bool)
+
+	pods, err := listPods(namespace, appSelector(label))
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		objects["Pod/"+pod.Name] = true
+	}
+
+	deployment, err := findDeploymentForApp(namespace, label)
+	if err != nil {
+		// Bare pods have no deployment, their events are still worth showing
+		return objects, nil
+	}
+	objects["Deployment/"+deployment.Name] = true
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		selector = labels.Everything()
+	}
+	replicaSets, err := listReplicaSets(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, replicaSet := range replicaSets {
+		if metav1.IsControlledBy(replicaSet, deployment) {
+			objects["ReplicaSet/"+replicaSet.Name] = true
+		}
+	}
+	return objects, nil
+}
+
+// groupEvents merges events with the same type, reason and message, summing
+// their counts. Warnings come first, then the most recent groups.
+func groupEvents(events []corev1.Event) []*eventGroup {
+	byKey := make(map[string]*eventGroup)
+	var groups []*eventGroup
+	for _, event := range events {
+		key := event.Type + "\x00" + event.Reason + "\x00" + event.Message
+		group, ok := byKey[key]
+		if !ok {
+			group = &eventGroup{eventType: event.Type, reason: event.Reason, message: event.Message}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+
+		first, last, count := eventTimes(event)
+		group.count += count
+		if group.firstSeen.IsZero() || first.Before(group.firstSeen) {
+			group.firstSeen = first
+		}
+		if last.After(group.lastSeen) {
+			group.lastSeen = last
+		}
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		if !slices.Contains(group.objects, object) {
+			group.objects = append(group.objects, object)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].eventType != groups[j].eventType {
+			return groups[i].eventType == corev1.EventTypeWarning
+		}
+		return groups[i].lastSeen.After(groups[j].lastSeen)
+	})
+	return groups
+}
+
+// eventTimes returns when an event was first and last seen and how many times
+// it occurred, for both the core/v1 fields and the newer event series.
+func eventTimes(event corev1.Event) (time.Time, time.Time, int32) {
+	first := event.FirstTimestamp.Time
+	last := event.LastTimestamp.Time
+	count := event.Count
+	if event.Series != nil {
+		last = event.Series.LastObservedTime.Time
+		count = event.Series.Count
+	}
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if first.IsZero() {
+		first = event.CreationTimestamp.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	if count == 0 {
+		count = 1
+	}
+	return first, last, count
+}
+
+// formatEventGroup renders a group of events on a single line.
+func formatEventGroup(group *eventGroup, now time.Time) string {
+	icon := ":information_source:"
+	if group.eventType == corev1.EventTypeWarning {
+		icon = ":warning:"
+	}
+
+	objects := group.objects
+	more := ""
+	if len(objects) > maxEventGroupObjects {
+		more = fmt.Sprintf(" +%d more", len(objects)-maxEventGroupObjects)
+		objects = objects[:maxEventGroupObjects]
+	}
+
+	message := group.message
+	// Cut on a rune boundary, so that messages in other scripts stay valid UTF-8
+	if runes := []rune(message); len(runes) > maxEventMessageLength {
+		message = string(runes[:maxEventMessageLength]) + "…"
+	}
+
+	seen := fmt.Sprintf("%s ago", formatAge(now.Sub(group.lastSeen)))
+	if group.count > 1 {
+		seen = fmt.Sprintf("x%d over %s, last %s", group.count, formatAge(now.Sub(group.firstSeen)), seen)
+	}
+	return fmt.Sprintf("%s `%s` %s on `%s`%s: %s", icon, group.reason, seen, strings.Join(objects, "`, `"), more, message)
+}
+
+// formatAge renders a duration the way kubectl shows ages, e.g. 45s, 12m, 3h or 2d.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		if d < 0 {
+			d = 0
+		}
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
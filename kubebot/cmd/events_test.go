@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testEvent returns an event of the object that occurred count times between first and last.
+func testEvent(eventType, reason, message, kind, name string, count int32, first, last time.Time) corev1.Event {
+	return corev1.Event{
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+		Count:          count,
+		FirstTimestamp: metav1.NewTime(first),
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func TestGroupEvents(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type group struct {
+		reason    string
+		count     int32
+		firstSeen time.Time
+		lastSeen  time.Time
+		objects   []string
+	}
+	tests := []struct {
+		name   string
+		events []corev1.Event
+		want   []group
+	}{
+		{
+			name: "same event of several pods is merged",
+			events: []corev1.Event{
+				testEvent(corev1.EventTypeWarning, "BackOff", "Back-off restarting failed container", "Pod", "kbot-1", 3, now.Add(-time.Hour), now.Add(-time.Minute)),
+				testEvent(corev1.EventTypeWarning, "BackOff", "Back-off restarting failed container", "Pod", "kbot-2", 2, now.Add(-2*time.Hour), now.Add(-10*time.Minute)),
+				testEvent(corev1.EventTypeWarning, "BackOff", "Back-off restarting failed container", "Pod", "kbot-1", 1, now.Add(-30*time.Minute), now.Add(-30*time.Minute)),
+			},
+			want: []group{
+				{reason: "BackOff", count: 6, firstSeen: now.Add(-2 * time.Hour), lastSeen: now.Add(-time.Minute), objects: []string{"pod/kbot-1", "pod/kbot-2"}},
+			},
+		},
+		{
+			name: "warnings first, then the most recent",
+			events: []corev1.Event{
+				testEvent(corev1.EventTypeNormal, "Pulled", "Pulled image", "Pod", "kbot-1", 1, now.Add(-time.Minute), now.Add(-time.Minute)),
+				testEvent(corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up", "Deployment", "kbot", 1, now.Add(-5*time.Minute), now.Add(-5*time.Minute)),
+				testEvent(corev1.EventTypeWarning, "FailedScheduling", "0/3 nodes are available", "Pod", "kbot-2", 1, now.Add(-time.Hour), now.Add(-time.Hour)),
+			},
+			want: []group{
+				{reason: "FailedScheduling", count: 1, firstSeen: now.Add(-time.Hour), lastSeen: now.Add(-time.Hour), objects: []string{"pod/kbot-2"}},
+				{reason: "Pulled", count: 1, firstSeen: now.Add(-time.Minute), lastSeen: now.Add(-time.Minute), objects: []string{"pod/kbot-1"}},
+				{reason: "ScalingReplicaSet", count: 1, firstSeen: now.Add(-5 * time.Minute), lastSeen: now.Add(-5 * time.Minute), objects: []string{"deployment/kbot"}},
+			},
+		},
+		{
+			name: "event series and missing counts",
+			events: []corev1.Event{
+				func() corev1.Event {
+					event := testEvent(corev1.EventTypeWarning, "Unhealthy", "Readiness probe failed", "Pod", "kbot-1", 0, time.Time{}, time.Time{})
+					event.EventTime = metav1.NewMicroTime(now.Add(-time.Hour))
+					event.Series = &corev1.EventSeries{Count: 12, LastObservedTime: metav1.NewMicroTime(now.Add(-time.Second))}
+					return event
+				}(),
+				testEvent(corev1.EventTypeWarning, "Unhealthy", "Readiness probe failed", "Pod", "kbot-2", 0, now.Add(-time.Minute), time.Time{}),
+			},
+			want: []group{
+				{reason: "Unhealthy", count: 13, firstSeen: now.Add(-time.Hour), lastSeen: now.Add(-time.Second), objects: []string{"pod/kbot-1", "pod/kbot-2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupEvents(tt.events)
+			if len(got) != len(tt.want) {
+				t.Fatalf("groupEvents() returned %d groups, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if g.reason != want.reason || g.count != want.count || !g.firstSeen.Equal(want.firstSeen) || !g.lastSeen.Equal(want.lastSeen) {
+					t.Errorf("group %d = %s x%d %s-%s, want %s x%d %s-%s", i, g.reason, g.count, g.firstSeen, g.lastSeen, want.reason, want.count, want.firstSeen, want.lastSeen)
+				}
+				if len(g.objects) != len(want.objects) {
+					t.Errorf("group %d objects = %v, want %v", i, g.objects, want.objects)
+					continue
+				}
+				for j := range want.objects {
+					if g.objects[j] != want.objects[j] {
+						t.Errorf("group %d objects = %v, want %v", i, g.objects, want.objects)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFormatEventGroupTruncatesMessage(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	group := &eventGroup{
+		eventType: corev1.EventTypeWarning,
+		reason:    "Failed",
+		message:   strings.Repeat("помилка ", 50),
+		objects:   []string{"pod/kbot-1"},
+		count:     1,
+		firstSeen: now.Add(-time.Minute),
+		lastSeen:  now.Add(-time.Minute),
+	}
+
+	got := formatEventGroup(group, now)
+	if !utf8.ValidString(got) {
+		t.Fatalf("formatEventGroup() = %q, want valid UTF-8", got)
+	}
+	_, message, _ := strings.Cut(got, "`pod/kbot-1`: ")
+	if !strings.HasSuffix(message, "…") || utf8.RuneCountInString(message) != maxEventMessageLength+1 {
+		t.Errorf("message = %q (%d runes), want %d runes and an ellipsis", message, utf8.RuneCountInString(message), maxEventMessageLength)
+	}
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fluxKind is a Flux custom resource the bot reads.
+type fluxKind struct {
+	schema.GroupKind
+	// revision returns the field that tells which revision or image the object is at.
+	revision func(obj *unstructured.Unstructured) string
+}
+
+// Flux kinds, in the order a change flows through them.
+var (
+	fluxImageRepository = fluxKind{
+		GroupKind: schema.GroupKind{Group: "image.toolkit.fluxcd.io", Kind: "ImageRepository"},
+		revision: func(obj *unstructured.Unstructured) string {
+			tags, found, _ := unstructured.NestedInt64(obj.Object, "status", "lastScanResult", "tagCount")
+			if !found {
+				return ""
+			}
+			return fmt.Sprintf("%d tags", tags)
+		},
+	}
+	fluxImagePolicy = fluxKind{
+		GroupKind: schema.GroupKind{Group: "image.toolkit.fluxcd.io", Kind: "ImagePolicy"},
+		revision: func(obj *unstructured.Unstructured) string {
+			return nestedString(obj, "status", "latestImage")
+		},
+	}
+	fluxImageUpdateAutomation = fluxKind{
+		GroupKind: schema.GroupKind{Group: "image.toolkit.fluxcd.io", Kind: "ImageUpdateAutomation"},
+		revision: func(obj *unstructured.Unstructured) string {
+			return nestedString(obj, "status", "lastPushCommit")
+		},
+	}
+	fluxGitRepository = fluxKind{
+		GroupKind: schema.GroupKind{Group: "source.toolkit.fluxcd.io", Kind: "GitRepository"},
+		revision: func(obj *unstructured.Unstructured) string {
+			return nestedString(obj, "status", "artifact", "revision")
+		},
+	}
+	fluxKustomization = fluxKind{
+		GroupKind: schema.GroupKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"},
+		revision: func(obj *unstructured.Unstructured) string {
+			return nestedString(obj, "status", "lastAppliedRevision")
+		},
+	}
+	fluxHelmRelease = fluxKind{
+		GroupKind: schema.GroupKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"},
+		revision: func(obj *unstructured.Unstructured) string {
+			if revision := nestedString(obj, "status", "lastAppliedRevision"); revision != "" {
+				return revision
+			}
+			return nestedString(obj, "status", "lastAttemptedRevision")
+		},
+	}
+)
+
+// fluxKinds lists the kinds shown by /flux.
+var fluxKinds = []fluxKind{fluxImageRepository, fluxImagePolicy, fluxImageUpdateAutomation, fluxGitRepository, fluxKustomization, fluxHelmRelease}
+
+// fluxStatus is the state of a Flux object as reported by its Ready condition.
+type fluxStatus struct {
+	Kind      string
+	Name      string
+	Ready     metav1.ConditionStatus
+	Reason    string
+	Message   string
+	Suspended bool
+	Revision  string
+	// ImageRepository is the repository an ImagePolicy selects images from.
+	ImageRepository string
+}
+
+// listFluxObjects returns the status of the objects of a Flux kind in the
+// namespace. Kinds whose CRDs are not installed yield no objects.
+func listFluxObjects(ctx context.Context, kind fluxKind, namespace string) ([]fluxStatus, error) {
+	mapping, err := restMapper.RESTMapping(kind.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to resolve %s: %w", kind.Kind, err)
+	}
+
+	list, err := dynamicClient.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s in namespace %s: %w", kind.Kind, namespace, err)
+	}
+
+	statuses := make([]fluxStatus, 0, len(list.Items))
+	for i := range list.Items {
+		obj := &list.Items[i]
+		status := fluxStatus{
+			Kind:            kind.Kind,
+			Name:            obj.GetName(),
+			Ready:           metav1.ConditionUnknown,
+			Revision:        kind.revision(obj),
+			ImageRepository: nestedString(obj, "spec", "imageRepositoryRef", "name"),
+		}
+		status.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != "Ready" {
+				continue
+			}
+			status.Ready = metav1.ConditionStatus(fmt.Sprint(condition["status"]))
+			status.Reason, _ = condition["reason"].(string)
+			status.Message, _ = condition["message"].(string)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// fluxStatusesForApp returns the Flux objects in the namespace, limited to
+// those of the app when label is set: its ImagePolicy, the ImageRepository the
+// policy reads, and the GitRepository, HelmRelease or Kustomization named after the app.
+// ImageUpdateAutomations are shared by the apps of a namespace and always included.
+func fluxStatusesForApp(ctx context.Context, namespace, label string) ([]fluxStatus, error) {
+	var all []fluxStatus
+	for _, kind := range fluxKinds {
+		statuses, err := listFluxObjects(ctx, kind, namespace)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, statuses...)
+	}
+	if label == "" {
+		return all, nil
+	}
+
+	policyName := label
+	if app := botConfig.app(label); app != nil {
+		policyName = app.ImagePolicy
+	}
+	names := map[string]bool{label: true, policyName: true}
+	for _, status := range all {
+		if status.Kind == fluxImagePolicy.Kind && status.Name == policyName && status.ImageRepository != "" {
+			names[status.ImageRepository] = true
+		}
+	}
+
+	var statuses []fluxStatus
+	for _, status := range all {
+		if names[status.Name] || status.Kind == fluxImageUpdateAutomation.Kind {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// String renders the status of a Flux object on a single line.
+func (s fluxStatus) String() string {
+	icon, state := ":hourglass:", "Unknown"
+	switch {
+	case s.Suspended:
+		icon, state = ":double_vertical_bar:", "Suspended"
+	case s.Ready == metav1.ConditionTrue:
+		icon, state = ":white_check_mark:", "Ready"
+	case s.Ready == metav1.ConditionFalse:
+		icon, state = ":x:", "Not ready"
+	}
+
+	line := fmt.Sprintf("%s `%s/%s` %s", icon, s.Kind, s.Name, state)
+	if s.Revision != "" {
+		line += fmt.Sprintf(", at `%s`", s.Revision)
+	}
+	if s.Ready != metav1.ConditionTrue && s.Message != "" {
+		line += fmt.Sprintf(": %s", s.Message)
+	}
+	return line
+}
+
+// fluxSummary returns the status of the Flux objects of an app for progress
+// messages, or an empty string if they cannot be read.
+func fluxSummary(namespace, label string) string {
+	statuses, err := fluxStatusesForApp(context.TODO(), namespace, label)
+	if err != nil || len(statuses) == 0 {
+		return ""
+	}
+	lines := make([]string, len(statuses))
+	for i, status := range statuses {
+		lines[i] = status.String()
+	}
+	return "Flux:\n" + strings.Join(lines, "\n")
+}
+
+// handleFluxCommand posts the status of the Flux objects in a namespace,
+// optionally limited to those of an app.
+func handleFluxCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/flux").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) < 1 || len(parts) > 2 {
+		totalErrors.WithLabelValues("/flux").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /flux <namespace> [label]")
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("flux") {
+		totalErrors.WithLabelValues("/flux").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for Flux status. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("flux")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "flux"); err != nil {
+		totalErrors.WithLabelValues("/flux").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	namespace := env.Namespace
+	label := ""
+	if len(parts) == 2 {
+		label = parts[1]
+	}
+
+	statuses, err := fluxStatusesForApp(context.TODO(), namespace, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/flux").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to get Flux status: %s", err))
+	}
+	if len(statuses) == 0 {
+		return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("No Flux objects found in namespace `%s`.", namespace))
+	}
+
+	lines := make([]string, len(statuses))
+	for i, status := range statuses {
+		lines[i] = status.String()
+	}
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Flux objects in namespace `%s`:\n%s", namespace, strings.Join(lines, "\n")))
+}
+
+// nestedString returns a string field of an object, or an empty string if it is missing.
+func nestedString(obj *unstructured.Unstructured, fields ...string) string {
+	value, _, _ := unstructured.NestedString(obj.Object, fields...)
+	return value
+}
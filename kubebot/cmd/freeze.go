@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// forceFlag lets admins promote to or roll back a frozen environment.
+const forceFlag = "--force"
+
+// freezeExpiryInterval is how often freezes that reached their end are lifted.
+const freezeExpiryInterval = time.Minute
+
+// errAlreadyFrozen is returned by CreateFreeze when the environment already has
+// a freeze that has not been lifted, e.g. created concurrently by another replica.
+var errAlreadyFrozen = errors.New("environment is already frozen")
+
+// freezeSuspendKinds are the Flux kinds suspended by freezes of environments
+// with suspendFlux, so that Flux stops changing the environment as well.
+var freezeSuspendKinds = []fluxKind{fluxImageUpdateAutomation, fluxKustomization}
+
+// Freeze is a lock on an environment that stops promotions and rollbacks.
+type Freeze struct {
+	ID          int64
+	Environment string
+	Reason      string
+	FrozenBy    string
+	ChannelID   string
+	// Suspended lists the Flux objects the freeze suspended as Kind/name, so
+	// that only those are resumed when it is lifted.
+	Suspended []string
+	CreatedAt time.Time
+	// ExpiresAt is when the freeze ends by itself, zero if it lasts until /unfreeze.
+	ExpiresAt time.Time
+	LiftedBy  string
+	LiftedAt  time.Time
+}
+
+// FreezeStore persists environment freezes, so that they survive restarts and
+// are seen by every replica of the bot.
+type FreezeStore interface {
+	// CreateFreeze adds a new active freeze and sets its ID. It returns
+	// errAlreadyFrozen if the environment has a freeze that is not lifted yet.
+	CreateFreeze(f *Freeze) error
+	// SetFreezeSuspended records the Flux objects the freeze suspended.
+	SetFreezeSuspended(id int64, suspended []string) error
+	// GetActiveFreeze returns the freeze of the environment that is neither
+	// lifted nor expired at now, or nil if the environment is not frozen.
+	GetActiveFreeze(environment string, now time.Time) (*Freeze, error)
+	// LiftFreeze ends the freeze and reports false if it was already lifted.
+	LiftFreeze(id int64, liftedBy string) (bool, error)
+	// ListExpiredFreezes returns the freezes that expired before now but have not been lifted yet.
+	ListExpiredFreezes(now time.Time) ([]Freeze, error)
+}
+
+// FreezeConfig describes what freezing an environment does besides blocking the bot.
+type FreezeConfig struct {
+	// SuspendFlux suspends the ImageUpdateAutomations and Kustomizations of the
+	// namespace while the environment is frozen.
+	SuspendFlux bool `yaml:"suspendFlux"`
+}
+
+// String describes the freeze for user facing messages.
+func (f *Freeze) String() string {
+	text := fmt.Sprintf("frozen by <@%s> since %s", f.FrozenBy, f.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	if !f.ExpiresAt.IsZero() {
+		text += fmt.Sprintf(" until %s", f.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+	}
+	if f.Reason != "" {
+		text += fmt.Sprintf(": %s", f.Reason)
+	}
+	return text
+}
+
+// handleFreezeCommand freezes an environment, so that /promote and /rollback
+// refuse to change it until it is unfrozen or the freeze expires.
+func handleFreezeCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/freeze").Inc()
+	commandText := command.Command + " " + command.Text
+	usage := "Invalid command format. Expected format: /freeze <namespace> [--for duration] [reason]"
+
+	parts := strings.Fields(command.Text)
+	if len(parts) < 1 {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, usage)
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("freeze") {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for freezing. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("freeze")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "freeze"); err != nil {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+
+	now := time.Now().UTC()
+	freeze := &Freeze{Environment: env.Name, FrozenBy: command.UserID, ChannelID: command.ChannelID, CreatedAt: now}
+	rest := parts[1:]
+	if len(rest) > 0 && rest[0] == "--for" {
+		if len(rest) < 2 {
+			totalErrors.WithLabelValues("/freeze").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, usage)
+		}
+		duration, err := time.ParseDuration(rest[1])
+		if err != nil || duration <= 0 {
+			totalErrors.WithLabelValues("/freeze").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Invalid duration `%s`, expected e.g. 30m or 2h. %s", rest[1], usage))
+		}
+		freeze.ExpiresAt = now.Add(duration)
+		rest = rest[2:]
+	}
+	freeze.Reason = strings.Join(rest, " ")
+
+	current, err := store.GetActiveFreeze(env.Name, now)
+	if err != nil {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to check whether `%s` is frozen: %s", env.Name, err))
+	}
+	if current != nil {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Environment `%s` is already %s.", env.Name, current))
+	}
+
+	// A freeze that expired but has not been lifted by the expiry loop yet still holds the environment
+	liftExpiredFreezes(client, env.Name, now)
+
+	// Create the freeze before suspending Flux, so that of concurrent freezes only the one that was stored suspends it
+	if err := store.CreateFreeze(freeze); err != nil {
+		totalErrors.WithLabelValues("/freeze").Inc()
+		if errors.Is(err, errAlreadyFrozen) {
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Environment `%s` has just been frozen by someone else.", env.Name))
+		}
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to freeze `%s`: %s", env.Name, err))
+	}
+
+	var warning string
+	if env.Freeze.SuspendFlux {
+		suspended, err := suspendFluxObjects(context.TODO(), env.Namespace)
+		freeze.Suspended = suspended
+		if err != nil {
+			warning = fmt.Sprintf("\n:warning: Failed to suspend Flux: %s", err)
+		}
+		if len(suspended) > 0 {
+			if err := store.SetFreezeSuspended(freeze.ID, suspended); err != nil {
+				// Without the record lifting the freeze would not resume them, so do not leave them suspended
+				resumeFluxObjects(context.TODO(), env.Namespace, suspended)
+				freeze.Suspended = nil
+				warning = fmt.Sprintf("\n:warning: Failed to record the suspended Flux objects, resumed them: %s", err)
+			}
+		}
+	}
+
+	message := fmt.Sprintf(":snowflake: Environment `%s` is now %s. `/promote` and `/rollback` are refused until `/unfreeze %s`.", env.Name, freeze, env.Name)
+	if len(freeze.Suspended) > 0 {
+		message += fmt.Sprintf("\nSuspended Flux objects: `%s`", strings.Join(freeze.Suspended, "`, `"))
+	}
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, message+warning)
+}
+
+// handleUnfreezeCommand lifts the freeze of an environment and resumes the
+// Flux objects it suspended.
+func handleUnfreezeCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/unfreeze").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) != 1 {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /unfreeze <namespace>")
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("unfreeze") {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for unfreezing. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("unfreeze")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "unfreeze"); err != nil {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+
+	freeze, err := store.GetActiveFreeze(env.Name, time.Now())
+	if err != nil {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to check whether `%s` is frozen: %s", env.Name, err))
+	}
+	if freeze == nil {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Environment `%s` is not frozen.", env.Name))
+	}
+
+	message, lifted, err := liftFreeze(freeze, command.UserID)
+	if err != nil {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to unfreeze `%s`: %s", env.Name, err))
+	}
+	if !lifted {
+		totalErrors.WithLabelValues("/unfreeze").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Environment `%s` has already been unfrozen.", env.Name))
+	}
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, message)
+}
+
+// liftFreeze ends the freeze, resumes the Flux objects it suspended and
+// returns the message announcing it. liftedBy is empty when the freeze expired.
+// It reports false if the freeze was already lifted, e.g. by another replica.
+func liftFreeze(freeze *Freeze, liftedBy string) (string, bool, error) {
+	lifted, err := store.LiftFreeze(freeze.ID, liftedBy)
+	if err != nil || !lifted {
+		return "", false, err
+	}
+
+	message := fmt.Sprintf(":sunny: Environment `%s` has been unfrozen by <@%s>. `/promote` and `/rollback` are allowed again.", freeze.Environment, liftedBy)
+	if liftedBy == "" {
+		message = fmt.Sprintf(":sunny: The freeze of environment `%s` has expired. `/promote` and `/rollback` are allowed again.", freeze.Environment)
+	}
+
+	if env := botConfig.environment(freeze.Environment); env != nil && len(freeze.Suspended) > 0 {
+		if err := resumeFluxObjects(context.TODO(), env.Namespace, freeze.Suspended); err != nil {
+			message += fmt.Sprintf("\n:warning: Failed to resume Flux: %s", err)
+		} else {
+			message += fmt.Sprintf("\nResumed Flux objects: `%s`", strings.Join(freeze.Suspended, "`, `"))
+		}
+	}
+	return message, true, nil
+}
+
+// checkFreeze returns an error describing why the command may not change the
+// environment, or nil if it is not frozen or an admin overrides the freeze with --force.
+func checkFreeze(env *Environment, userID, command string, force bool) error {
+	freeze, err := store.GetActiveFreeze(env.Name, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check whether `%s` is frozen: %w", env.Name, err)
+	}
+	if freeze == nil {
+		return nil
+	}
+	if force && botConfig.roleOf(userID, env) == roleAdmin {
+		log.Printf("User %s overrode the freeze of %s with %s %s", userID, env.Name, command, forceFlag)
+		return nil
+	}
+	return fmt.Errorf(":snowflake: Environment `%s` is %s. Run `/unfreeze %s` first, or ask an admin to run `%s` with `%s`", env.Name, freeze, env.Name, command, forceFlag)
+}
+
+// withoutForceFlag removes --force from the command arguments and reports whether it was given.
+func withoutForceFlag(parts []string) ([]string, bool) {
+	var args []string
+	force := false
+	for _, part := range parts {
+		if part == forceFlag {
+			force = true
+			continue
+		}
+		args = append(args, part)
+	}
+	return args, force
+}
+
+// suspendFluxObjects suspends the Flux objects of the kinds in
+// freezeSuspendKinds in the namespace and returns those it suspended as
+// Kind/name. Objects suspended by someone else are left out, so that lifting
+// the freeze does not resume them.
+func suspendFluxObjects(ctx context.Context, namespace string) ([]string, error) {
+	var suspended []string
+	for _, kind := range freezeSuspendKinds {
+		statuses, err := listFluxObjects(ctx, kind, namespace)
+		if err != nil {
+			return suspended, err
+		}
+		for _, status := range statuses {
+			if status.Suspended {
+				continue
+			}
+			if err := setFluxSuspend(ctx, kind, namespace, status.Name, true); err != nil {
+				return suspended, err
+			}
+			suspended = append(suspended, kind.Kind+"/"+status.Name)
+		}
+	}
+	return suspended, nil
+}
+
+// resumeFluxObjects resumes the Flux objects given as Kind/name. It carries on
+// past failures, so that one missing object does not keep the others suspended,
+// and returns the first one.
+func resumeFluxObjects(ctx context.Context, namespace string, objects []string) error {
+	var firstErr error
+	for _, object := range objects {
+		kindName, name, _ := strings.Cut(object, "/")
+		var err error
+		if kind, ok := fluxKindByName(kindName); ok {
+			err = setFluxSuspend(ctx, kind, namespace, name, false)
+		} else {
+			err = fmt.Errorf("unknown Flux kind %s", kindName)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to resume %s: %w", object, err)
+		}
+	}
+	return firstErr
+}
+
+// setFluxSuspend sets spec.suspend of a Flux object.
+func setFluxSuspend(ctx context.Context, kind fluxKind, namespace, name string, suspend bool) error {
+	mapping, err := restMapper.RESTMapping(kind.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return fmt.Errorf("%s is not installed in the cluster", kind.Kind)
+		}
+		return fmt.Errorf("failed to resolve %s: %w", kind.Kind, err)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"suspend": suspend},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build suspend patch: %w", err)
+	}
+	_, err = dynamicClient.Resource(mapping.Resource).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// fluxKindByName returns the Flux kind with the given Kind, e.g. Kustomization.
+func fluxKindByName(name string) (fluxKind, bool) {
+	for _, kind := range fluxKinds {
+		if kind.Kind == name {
+			return kind, true
+		}
+	}
+	return fluxKind{}, false
+}
+
+// startFreezeExpiry periodically lifts the freezes that reached their end,
+// resumes the Flux objects they suspended and announces it in the channel the
+// environment was frozen from, until the context is cancelled.
+func startFreezeExpiry(ctx context.Context, client *slack.Client) {
+	ticker := time.NewTicker(freezeExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			liftExpiredFreezes(client, "", time.Now())
+		}
+	}
+}
+
+// liftExpiredFreezes lifts the freezes of the environment, or of every
+// environment if it is empty, that reached their end before now, and
+// announces it in the channel each one was created from.
+func liftExpiredFreezes(client *slack.Client, environment string, now time.Time) {
+	freezes, err := store.ListExpiredFreezes(now)
+	if err != nil {
+		log.Printf("Failed to list expired freezes: %v", err)
+		return
+	}
+	for i := range freezes {
+		if environment != "" && freezes[i].Environment != environment {
+			continue
+		}
+		message, lifted, err := liftFreeze(&freezes[i], "")
+		if err != nil {
+			log.Printf("Failed to lift expired freeze %d of %s: %v", freezes[i].ID, freezes[i].Environment, err)
+			continue
+		}
+		if !lifted || freezes[i].ChannelID == "" {
+			continue
+		}
+		if _, _, err := client.PostMessage(freezes[i].ChannelID, slack.MsgOptionText(message, false)); err != nil {
+			log.Printf("Failed to announce the end of freeze %d: %v", freezes[i].ID, err)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v32/github"
+	"github.com/slack-go/slack"
 	"golang.org/x/oauth2"
 )
 
@@ -25,52 +29,197 @@ func initGitHubClient() {
 	githubClient = github.NewClient(tc)
 }
 
-// Updates the version in a specific GitHub file within a repository.
-func updateVersionInGitHubFile(namespace, newVersion, commandType string) error {
-    ctx := context.Background()
-    owner := os.Getenv("GITHUB_OWNER")
-    repo := os.Getenv("GITHUB_REPO")
-    path := fmt.Sprintf("clusters/kbot/%s/image-policy.yaml", namespace)
-
-    // Determine the branch based on the namespace
-    branch := "main" // Default branch
-    if namespace == "prod" {
-        branch = "prod" // Switch to the production branch for the 'prod' namespace
-    }
-
-    // Setting options to get the file content from the specified branch
-    opts := &github.RepositoryContentGetOptions{Ref: branch}
-
-    // Retrieving the current content of the file
-    fileContent, _, _, err := githubClient.Repositories.GetContents(ctx, owner, repo, path, opts)
-    if err != nil {
-        return fmt.Errorf("failed to retrieve file content: %w", err)
-    }
-
-    decodedContent, err := fileContent.GetContent() // Decoding the content of the file
-    if err != nil {
-        return fmt.Errorf("failed to decode file content: %w", err)
-    }
-
-    // Check if the 'range' field needs to be updated
-    if !strings.Contains(decodedContent, fmt.Sprintf("range: '%s'", newVersion)) {
-        // Updating the 'range' field and commit the changes
-        updatedContent := regexp.MustCompile(`range: '.*'`).ReplaceAllString(decodedContent, fmt.Sprintf("range: '%s'", newVersion))
-
-        message := fmt.Sprintf("%s version %s to %s", commandType, newVersion, namespace) // Creating commit message
-        updateOpts := &github.RepositoryContentFileOptions{
-            Message: github.String(message),
-            Content: []byte(updatedContent),
-            SHA:     fileContent.SHA,
-            Branch:  github.String(branch),
-        }
-
-        // Updating the file with the new content
-        _, _, err = githubClient.Repositories.UpdateFile(ctx, owner, repo, path, updateOpts)
-        if err != nil {
-            return fmt.Errorf("failed to update file: %w", err)
-        }
-    }
-
-    return nil
+// Promotion modes of an app's GitOps changes.
+const (
+	// promotionModeDirect commits the change straight to the app's branch.
+	promotionModeDirect = "direct"
+	// promotionModePullRequest commits the change to a new branch and opens a pull request.
+	promotionModePullRequest = "pull_request"
+)
+
+// GitHubConfig tunes how the bot follows the pull requests it opens.
+type GitHubConfig struct {
+	// PullRequestPollInterval is how often an open pull request is checked for a merge. Defaults to 30s.
+	PullRequestPollInterval time.Duration `yaml:"pullRequestPollInterval"`
+	// PullRequestTimeout is how long to wait for a pull request to be merged. Defaults to 24h.
+	PullRequestTimeout time.Duration `yaml:"pullRequestTimeout"`
+}
+
+// errAlreadyAtVersion is returned by updateVersionInGitHubFile when the
+// ImagePolicy file already pins the requested version, so nothing was committed.
+var errAlreadyAtVersion = errors.New("the GitOps repository already pins this version")
+
+// invalidBranchChars matches the characters that are not kept in generated branch names.
+var invalidBranchChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Updates the version in the ImagePolicy file of the app for the given environment
+// within the app's GitOps repository. In the pull_request mode the change is
+// committed to a new branch and the opened pull request is returned; otherwise
+// it is committed to the app's branch and the returned pull request is nil.
+// errAlreadyAtVersion is returned when the file already pins the version.
+func updateVersionInGitHubFile(app *App, env *Environment, newVersion, commandType, requestedBy string) (*github.PullRequest, error) {
+	ctx := context.Background()
+	owner, repo := app.Owner, app.Repo
+	path, err := app.pathFor(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine the branch based on the app and environment
+	branch := app.branchFor(env)
+
+	// Setting options to get the file content from the specified branch
+	opts := &github.RepositoryContentGetOptions{Ref: branch}
+
+	// Retrieving the current content of the file
+	fileContent, _, _, err := githubClient.Repositories.GetContents(ctx, owner, repo, path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file content of %s/%s/%s@%s: %w", owner, repo, path, branch, err)
+	}
+
+	decodedContent, err := fileContent.GetContent() // Decoding the content of the file
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+
+	// Update spec.policy.semver.range of the app's ImagePolicy, if it needs to be updated
+	updatedContent, changed, err := setImagePolicyRange(decodedContent, app.ImagePolicy, env.Namespace, newVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if !changed {
+		return nil, errAlreadyAtVersion
+	}
+
+	message := fmt.Sprintf("%s version %s to %s", commandType, newVersion, env.Namespace) // Creating commit message
+
+	if app.Mode != promotionModePullRequest {
+		updateOpts := &github.RepositoryContentFileOptions{
+			Message: github.String(message),
+			Content: []byte(updatedContent),
+			SHA:     fileContent.SHA,
+			Branch:  github.String(branch),
+		}
+
+		// Updating the file with the new content
+		_, _, err = githubClient.Repositories.UpdateFile(ctx, owner, repo, path, updateOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update file: %w", err)
+		}
+		return nil, nil
+	}
+
+	// The first word of the command type, e.g. Promote or Rollback
+	action := commandType
+	if fields := strings.Fields(commandType); len(fields) > 0 {
+		action = fields[0]
+	}
+
+	// Create a branch for the change from the head of the base branch
+	baseRef, _, err := githubClient.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch %s: %w", branch, err)
+	}
+	headBranch := invalidBranchChars.ReplaceAllString(
+		fmt.Sprintf("slackbot/%s-%s-%s-%s-%d", strings.ToLower(action), app.Name, env.Name, newVersion, time.Now().Unix()), "-")
+	_, _, err = githubClient.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + headBranch),
+		Object: &github.GitObject{SHA: baseRef.Object.SHA},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create branch %s: %w", headBranch, err)
+	}
+
+	// Commit the updated file to the new branch
+	_, _, err = githubClient.Repositories.UpdateFile(ctx, owner, repo, path, &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: []byte(updatedContent),
+		SHA:     fileContent.SHA,
+		Branch:  github.String(headBranch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update file on branch %s: %w", headBranch, err)
+	}
+
+	// Open the pull request against the base branch
+	body := fmt.Sprintf("%s `%s` to version `%s` in the `%s` environment (namespace `%s`).\n\n"+
+		"| | |\n|---|---|\n| App | `%s` |\n| Environment | `%s` |\n| Version | `%s` |\n| File | `%s` |\n| Requested by | Slack user `%s` |\n\n"+
+		"Opened by Slackbot. The bot follows this pull request and reports the rollout in Slack once it is merged.",
+		action, app.Name, newVersion, env.Name, env.Namespace,
+		app.Name, env.Name, newVersion, path, requestedBy)
+	pr, _, err := githubClient.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(message),
+		Head:  github.String(headBranch),
+		Base:  github.String(branch),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request from %s: %w", headBranch, err)
+	}
+
+	return pr, nil
+}
+
+// desiredVersion reads the version the GitOps repository pins for the app in
+// the environment, i.e. spec.policy.semver.range of its ImagePolicy file.
+func desiredVersion(ctx context.Context, app *App, env *Environment) (string, error) {
+	path, err := app.pathFor(env)
+	if err != nil {
+		return "", err
+	}
+	branch := app.branchFor(env)
+
+	fileContent, _, _, err := githubClient.Repositories.GetContents(ctx, app.Owner, app.Repo, path, &github.RepositoryContentGetOptions{Ref: branch})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve file content of %s/%s/%s@%s: %w", app.Owner, app.Repo, path, branch, err)
+	}
+	content, err := fileContent.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode file content: %w", err)
+	}
+
+	version, err := imagePolicyRange(content, app.ImagePolicy, env.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return version, nil
+}
+
+// waitForPullRequestMerge polls the pull request until it is merged, closed or
+// the configured timeout passes, reporting the outcome in Slack. onMerged is
+// called once the pull request has been merged. The wait lives only in memory:
+// pull requests still open when the bot restarts are not followed any more.
+func waitForPullRequestMerge(app *App, pr *github.PullRequest, command string, client *slack.Client, channelID, userID string, onMerged func()) {
+	ctx, cancel := context.WithTimeout(lifecycleCtx, botConfig.GitHub.PullRequestTimeout)
+	defer cancel()
+	ticker := time.NewTicker(botConfig.GitHub.PullRequestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				log.Printf("Stopped waiting for pull request #%d of %s/%s: the bot is shutting down", pr.GetNumber(), app.Owner, app.Repo)
+				return
+			}
+			sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Pull request <%s|#%d> was not merged within %s. Stopped waiting for it.", pr.GetHTMLURL(), pr.GetNumber(), botConfig.GitHub.PullRequestTimeout))
+			return
+		case <-ticker.C:
+			current, _, err := githubClient.PullRequests.Get(ctx, app.Owner, app.Repo, pr.GetNumber())
+			if err != nil {
+				log.Printf("Failed to get pull request #%d of %s/%s: %v", pr.GetNumber(), app.Owner, app.Repo, err)
+				continue
+			}
+
+			if current.GetMerged() {
+				sendSuccessMessage(client, channelID, userID, command, fmt.Sprintf("Pull request <%s|#%d> has been merged by %s. Waiting for the deployment to complete.", current.GetHTMLURL(), current.GetNumber(), current.GetMergedBy().GetLogin()))
+				onMerged()
+				return
+			}
+			if current.GetState() == "closed" {
+				sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Pull request <%s|#%d> was closed without merging. Nothing will be deployed.", current.GetHTMLURL(), current.GetNumber()))
+				return
+			}
+		}
+	}
 }
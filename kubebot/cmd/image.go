@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultRegistry is the registry of image references that do not name one.
+const defaultRegistry = "docker.io"
+
+// imageReference is a parsed container image reference, e.g.
+// registry.local:5000/team/kbot:v1.2.0@sha256:0123...
+type imageReference struct {
+	// Registry is the registry host with an optional port. Defaults to docker.io.
+	Registry string
+	// Repository is the path of the image within the registry, e.g. team/kbot.
+	Repository string
+	// Tag is the image tag, empty if the reference has none.
+	Tag string
+	// Digest is the content digest including its algorithm, e.g. sha256:0123...,
+	// empty if the reference is not pinned.
+	Digest string
+}
+
+// parseImageReference splits a container image reference into its registry,
+// repository, tag and digest. A registry port is not mistaken for a tag, and
+// digest-pinned references with or without a tag are supported.
+func parseImageReference(image string) (imageReference, error) {
+	var ref imageReference
+	name := strings.TrimSpace(image)
+	if name == "" {
+		return ref, fmt.Errorf("empty image reference")
+	}
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest in image reference %q", image)
+		}
+	}
+
+	// The tag follows the last colon after the last slash; earlier colons belong to the registry port
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+		if ref.Tag == "" {
+			return ref, fmt.Errorf("empty tag in image reference %q", image)
+		}
+	}
+
+	// The first path component is a registry only if it looks like a host
+	ref.Registry = defaultRegistry
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			name = name[i+1:]
+		}
+	}
+
+	if name == "" {
+		return ref, fmt.Errorf("missing repository in image reference %q", image)
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// version returns the tag of the image, or its digest for images pinned by digest only.
+func (r imageReference) version() string {
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return r.Digest
+}
+
+// extractPodSpecVersion returns the version of the first container image in a
+// pod spec that has a tag or digest.
+func extractPodSpecVersion(spec corev1.PodSpec) (string, error) {
+	for _, container := range spec.Containers {
+		ref, err := parseImageReference(container.Image)
+		if err != nil {
+			continue
+		}
+		if version := ref.version(); version != "" {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("version not found")
+}
+
+// containerVersion returns the version of the named container in a pod spec,
+// or of the first container with a version if name is empty.
+func containerVersion(spec corev1.PodSpec, name string) (string, error) {
+	if name == "" {
+		return extractPodSpecVersion(spec)
+	}
+	for _, container := range spec.Containers {
+		if container.Name != name {
+			continue
+		}
+		ref, err := parseImageReference(container.Image)
+		if err != nil {
+			return "", err
+		}
+		if version := ref.version(); version != "" {
+			return version, nil
+		}
+		return "", fmt.Errorf("image of container %s has no version", name)
+	}
+	return "", fmt.Errorf("container %s not found", name)
+}
+
+// appVersion returns the version of the primary container of the app with the
+// given app.kubernetes.io/name label.
+func appVersion(spec corev1.PodSpec, label string) (string, error) {
+	var container string
+	if app := botConfig.app(label); app != nil {
+		container = app.Container
+	}
+	return containerVersion(spec, container)
+}
+
+// describeContainers lists the images of the init and regular containers of a
+// pod, marking the primary container of its app.
+func describeContainers(pod *corev1.Pod) []string {
+	var primary string
+	if app := botConfig.app(pod.Labels["app.kubernetes.io/name"]); app != nil {
+		primary = app.Container
+	}
+
+	var lines []string
+	for _, container := range pod.Spec.InitContainers {
+		lines = append(lines, fmt.Sprintf("    • init `%s`: `%s`", container.Name, container.Image))
+	}
+	for _, container := range pod.Spec.Containers {
+		line := fmt.Sprintf("    • `%s`: `%s`", container.Name, container.Image)
+		if container.Name == primary {
+			line += " (primary)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
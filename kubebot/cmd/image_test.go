@@ -0,0 +1,45 @@
+package cmd
+
+import "testing"
+
+func TestParseImageReference(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	tests := []struct {
+		image   string
+		want    imageReference
+		wantErr bool
+	}{
+		{image: "kbot", want: imageReference{Registry: defaultRegistry, Repository: "kbot"}},
+		{image: "kbot:v1.2.0", want: imageReference{Registry: defaultRegistry, Repository: "kbot", Tag: "v1.2.0"}},
+		{image: "team/kbot:v1.2.0", want: imageReference{Registry: defaultRegistry, Repository: "team/kbot", Tag: "v1.2.0"}},
+		{image: "ghcr.io/team/kbot:v1.2.0", want: imageReference{Registry: "ghcr.io", Repository: "team/kbot", Tag: "v1.2.0"}},
+		{image: "registry.local:5000/kbot", want: imageReference{Registry: "registry.local:5000", Repository: "kbot"}},
+		{image: "registry.local:5000/team/kbot:v1.2.0", want: imageReference{Registry: "registry.local:5000", Repository: "team/kbot", Tag: "v1.2.0"}},
+		{image: "localhost/kbot:dev", want: imageReference{Registry: "localhost", Repository: "kbot", Tag: "dev"}},
+		{image: "kbot@" + digest, want: imageReference{Registry: defaultRegistry, Repository: "kbot", Digest: digest}},
+		{image: "ghcr.io/team/kbot:v1.2.0@" + digest, want: imageReference{Registry: "ghcr.io", Repository: "team/kbot", Tag: "v1.2.0", Digest: digest}},
+		{image: " kbot:v1 ", want: imageReference{Registry: defaultRegistry, Repository: "kbot", Tag: "v1"}},
+		{image: "", wantErr: true},
+		{image: "kbot:", wantErr: true},
+		{image: "kbot@0123", wantErr: true},
+		{image: "ghcr.io/:v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := parseImageReference(tt.image)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseImageReference(%q) = %+v, want an error", tt.image, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseImageReference(%q) = %+v, want %+v", tt.image, got, tt.want)
+			}
+		})
+	}
+}
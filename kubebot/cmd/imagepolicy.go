@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// imagePolicyRangePath is the path of the version range inside an ImagePolicy object.
+var imagePolicyRangePath = []string{"spec", "policy", "semver", "range"}
+
+// findImagePolicyRange parses the YAML documents in content and returns the
+// scalar node of spec.policy.semver.range of the ImagePolicy with the given
+// name. Objects in another namespace are skipped when namespace is set and the
+// object declares one.
+func findImagePolicyRange(content, name, namespace string) (*yaml.Node, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		root := doc.Content[0]
+		if mappingValue(root, "kind").Value != "ImagePolicy" {
+			continue
+		}
+		metadata := mappingValue(root, "metadata")
+		if mappingValue(metadata, "name").Value != name {
+			continue
+		}
+		if ns := mappingValue(metadata, "namespace").Value; namespace != "" && ns != "" && ns != namespace {
+			continue
+		}
+
+		node := root
+		for _, key := range imagePolicyRangePath {
+			node = mappingValue(node, key)
+		}
+		if node.Kind != yaml.ScalarNode || node.Line == 0 {
+			return nil, fmt.Errorf("ImagePolicy %s has no %s", name, strings.Join(imagePolicyRangePath, "."))
+		}
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("ImagePolicy %s not found", name)
+}
+
+// imagePolicyRange returns spec.policy.semver.range of the named ImagePolicy in content.
+func imagePolicyRange(content, name, namespace string) (string, error) {
+	node, err := findImagePolicyRange(content, name, namespace)
+	if err != nil {
+		return "", err
+	}
+	return node.Value, nil
+}
+
+// setImagePolicyRange sets spec.policy.semver.range of the named ImagePolicy
+// in content to version. Only the value itself is rewritten, in its original
+// quoting style, so comments, formatting and other documents are preserved.
+// It reports whether the content changed.
+func setImagePolicyRange(content, name, namespace, version string) (string, bool, error) {
+	node, err := findImagePolicyRange(content, name, namespace)
+	if err != nil {
+		return "", false, err
+	}
+	if node.Value == version {
+		return content, false, nil
+	}
+
+	start, err := offsetOf(content, node.Line, node.Column)
+	if err != nil {
+		return "", false, err
+	}
+	end, err := scalarEnd(content, start, node.Style)
+	if err != nil {
+		return "", false, fmt.Errorf("ImagePolicy %s: %w", name, err)
+	}
+
+	updated := content[:start] + formatScalar(version, node.Style) + content[end:]
+
+	// Make sure the edit produced exactly the intended value
+	if value, err := imagePolicyRange(updated, name, namespace); err != nil || value != version {
+		return "", false, fmt.Errorf("failed to update ImagePolicy %s range to %s", name, version)
+	}
+	return updated, true, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or an empty node if there is none.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	}
+	return &yaml.Node{}
+}
+
+// offsetOf converts a 1-based line and column reported by the YAML parser into a byte offset.
+func offsetOf(content string, line, column int) (int, error) {
+	offset := 0
+	for l := 1; l < line; l++ {
+		next := strings.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			return 0, fmt.Errorf("line %d is out of range", line)
+		}
+		offset += next + 1
+	}
+	for c := 1; c < column; c++ {
+		if offset >= len(content) || content[offset] == '\n' {
+			return 0, fmt.Errorf("column %d of line %d is out of range", column, line)
+		}
+		// Columns count characters, so skip whole UTF-8 sequences
+		offset++
+		for offset < len(content) && content[offset]&0xC0 == 0x80 {
+			offset++
+		}
+	}
+	return offset, nil
+}
+
+// scalarEnd returns the offset just past the scalar that starts at start.
+func scalarEnd(content string, start int, style yaml.Style) (int, error) {
+	switch {
+	case style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			if content[i] == '\'' {
+				if i+1 < len(content) && content[i+1] == '\'' {
+					i++ // An escaped quote
+					continue
+				}
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated single-quoted range")
+	case style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated double-quoted range")
+	case style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return 0, fmt.Errorf("block scalar ranges are not supported")
+	default:
+		// A plain scalar runs to a comment or the end of the line
+		end := strings.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content) - start
+		}
+		line := content[start : start+end]
+		if comment := strings.Index(line, " #"); comment >= 0 {
+			line = line[:comment]
+		}
+		return start + len(strings.TrimRight(line, " \t\r")), nil
+	}
+}
+
+// formatScalar renders value as a YAML scalar in the given style. Plain values
+// that YAML would not read back as the same string are single-quoted.
+func formatScalar(value string, style yaml.Style) string {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		out, _ := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: value})
+		return strings.TrimSuffix(string(out), "\n")
+	case style&yaml.SingleQuotedStyle == 0:
+		var decoded interface{}
+		if err := yaml.Unmarshal([]byte(value), &decoded); err == nil && decoded == value && !strings.ContainsAny(value, "\n#") {
+			return value
+		}
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
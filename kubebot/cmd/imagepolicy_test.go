@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetImagePolicyRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		policy      string
+		namespace   string
+		version     string
+		want        string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name: "plain value keeps its comment",
+			content: `apiVersion: image.toolkit.fluxcd.io/v1beta2
+kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: v1.0.0 # {"$imagepolicy": "flux-system:kbot"}
+`,
+			policy:  "kbot",
+			version: "v1.1.0",
+			want: `apiVersion: image.toolkit.fluxcd.io/v1beta2
+kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: v1.1.0 # {"$imagepolicy": "flux-system:kbot"}
+`,
+			wantChanged: true,
+		},
+		{
+			name: "quoting style is kept",
+			content: `kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: "v1.0.0"
+`,
+			policy:  "kbot",
+			version: "v1.1.0",
+			want: `kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: "v1.1.0"
+`,
+			wantChanged: true,
+		},
+		{
+			name: "only the named policy in the namespace changes",
+			content: `kind: ImagePolicy
+metadata:
+  name: kbot
+  namespace: qa
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+---
+kind: ImagePolicy
+metadata:
+  name: kbot
+  namespace: prod
+spec:
+  policy:
+    semver:
+      range: v0.9.0
+`,
+			policy:    "kbot",
+			namespace: "prod",
+			version:   "v1.0.0",
+			want: `kind: ImagePolicy
+metadata:
+  name: kbot
+  namespace: qa
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+---
+kind: ImagePolicy
+metadata:
+  name: kbot
+  namespace: prod
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+`,
+			wantChanged: true,
+		},
+		{
+			name: "same version is left alone",
+			content: `kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+`,
+			policy:  "kbot",
+			version: "v1.0.0",
+			want: `kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+`,
+		},
+		{
+			name: "unknown policy",
+			content: `kind: ImagePolicy
+metadata:
+  name: other
+spec:
+  policy:
+    semver:
+      range: v1.0.0
+`,
+			policy:  "kbot",
+			version: "v1.1.0",
+			wantErr: true,
+		},
+		{
+			name: "policy without a semver range",
+			content: `kind: ImagePolicy
+metadata:
+  name: kbot
+spec:
+  policy:
+    alphabetical:
+      order: asc
+`,
+			policy:  "kbot",
+			version: "v1.1.0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := setImagePolicyRange(tt.content, tt.policy, tt.namespace, tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("setImagePolicyRange() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got != tt.want {
+				t.Errorf("setImagePolicyRange() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatScalar(t *testing.T) {
+	tests := []struct {
+		value string
+		style yaml.Style
+		want  string
+	}{
+		{value: "v1.2.0", want: "v1.2.0"},
+		{value: ">=1.0.0 <2.0.0", want: "'>=1.0.0 <2.0.0'"},
+		{value: "1.10", want: "'1.10'"},
+		{value: "true", want: "'true'"},
+		{value: "v1 #beta", want: "'v1 #beta'"},
+		{value: "it's", style: yaml.SingleQuotedStyle, want: "'it''s'"},
+		{value: "v1.2.0", style: yaml.SingleQuotedStyle, want: "'v1.2.0'"},
+		{value: "v1.2.0", style: yaml.DoubleQuotedStyle, want: `"v1.2.0"`},
+		{value: `say "hi"`, style: yaml.DoubleQuotedStyle, want: `"say \"hi\""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := formatScalar(tt.value, tt.style); got != tt.want {
+				t.Errorf("formatScalar(%q, %v) = %s, want %s", tt.value, tt.style, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,20 +2,21 @@ package cmd
 
 //
 import (
-	"context"
 	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
-	"strings"
+	"sort"
 	"time"
 
-	"github.com/slack-go/slack"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -23,6 +24,13 @@ import (
 // interactions with Kubernetes API server.
 var clientset *kubernetes.Clientset
 
+// dynamicClient reads and patches custom resources such as the Flux objects,
+// whose versions are resolved through restMapper.
+var (
+	dynamicClient dynamic.Interface
+	restMapper    meta.RESTMapper
+)
+
 // initKubernetesClient initializes the Kubernetes clientset used for interacting
 // with the Kubernetes cluster.
 func initKubernetesClient() error {
@@ -53,6 +61,13 @@ func initKubernetesClient() error {
 		return err
 	}
 
+	dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		log.Printf("Failed to create Kubernetes dynamic client: %v", err)
+		return err
+	}
+	restMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+
 	return nil
 }
 
@@ -90,43 +105,6 @@ func buildConfigFromEnvVars() (*rest.Config, error) {
 	return config, nil
 }
 
-// checkPodStatusAfterPromotion monitors pods in a namespace to confirm successful deployment of a target version.
-func checkPodStatusAfterPromotion(namespace, targetVersion string, command string, client *slack.Client, channelID, userID string) {
-	// create watcher for pods in a namespace
-	watcher, err := clientset.CoreV1().Pods(namespace).Watch(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to watch pods in namespace `%s`: %v", namespace, err)
-		sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Failed to watch pods in namespace `%s`", namespace))
-		return
-	}
-	defer watcher.Stop()
-
-	for event := range watcher.ResultChan() {
-		pod, ok := event.Object.(*corev1.Pod)
-		if !ok {
-			log.Println("Unexpected type")
-			continue
-		}
-
-		version, err := extractPodVersion(pod)
-		if err != nil {
-			log.Printf("Failed to extract version from pod `%s` in namespace `%s`", pod.Name, namespace)
-			continue // Skip if version extraction fails.
-		}
-
-		// Check if the pod with the updated version is launched and running
-		if event.Type == watch.Added || event.Type == watch.Modified {
-			if pod.Status.Phase == corev1.PodRunning && version == targetVersion {
-				sendSuccessMessage(client, channelID, userID, command, fmt.Sprintf("Pod `%s` with version `%s` in namespace `%s` is successfully running.", pod.Name, version, namespace))
-				return
-			} else if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodUnknown {
-				sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Pod `%s` with version `%s` in namespace `%s` has failed to start.", pod.Name, version, namespace))
-				// Continue monitoring; failure of one pod does not imply failure of promotion.
-			}
-		}
-	}
-}
-
 // getPodsInfoWithRetries attempts to retrieve information about pods in a specified namespace
 // with a specified number of retries and a delay between retries.
 func getPodsInfoWithRetries(namespace string, maxRetries int, retryDelay time.Duration) ([]string, []string, []string, error) {
@@ -149,37 +127,34 @@ func getPodsInfoWithRetries(namespace string, maxRetries int, retryDelay time.Du
 }
 
 // getPodsInfo retrieves information about pods in a specified namespace, including
-// their names, versions extracted from container images, and label selectors.
+// their names, versions extracted from the image of the app's primary container,
+// and label selectors.
 func getPodsInfo(namespace string) ([]string, []string, []string, error) {
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	pods, err := listPods(namespace, labels.Everything())
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
 	}
 
-	if len(pods.Items) == 0 {
+	if len(pods) == 0 {
 		return nil, nil, nil, fmt.Errorf("no pods found in namespace %s", namespace)
 	}
 
+	// The cache returns pods in no particular order
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
 	var podNames []string
 	var versions []string
 	var labelSelectors []string
 
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		podNames = append(podNames, pod.Name)
 
-		var version string
-		for _, container := range pod.Spec.Containers {
-			parts := strings.Split(container.Image, ":")
-			if len(parts) > 1 {
-				version = parts[1]
-				break
-			}
-		}
-		versions = append(versions, version)
-
-		labels := pod.Labels
-		labelSelector := labels["app.kubernetes.io/name"]
+		labelSelector := pod.Labels["app.kubernetes.io/name"]
 		labelSelectors = append(labelSelectors, labelSelector)
+
+		// The version is taken from the primary container of the app, ignoring sidecars
+		version, _ := appVersion(pod.Spec, labelSelector)
+		versions = append(versions, version)
 	}
 
 	return podNames, versions, labelSelectors, nil
@@ -187,31 +162,21 @@ func getPodsInfo(namespace string) ([]string, []string, []string, error) {
 
 // GetPodStatus retrieves the status of the specified pod in the given namespace.
 func GetPodStatus(podName, namespace string) (string, error) {
-	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	pod, err := getPod(namespace, podName)
 	if err != nil {
 		return "", fmt.Errorf("failed to get pod details: %w", err)
 	}
 
-	// Перевіряємо статус кожного контейнера в поді
-	for _, containerStatus := range pod.Status.ContainerStatuses {
-		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" {
-			// Return pod phase if containers are not waiting.(ex, CrashLoopBackOff)
-			return containerStatus.State.Waiting.Reason, nil
-		}
-	}
-
-	// Return pod phase if containers are not waiting.
-	return string(pod.Status.Phase), nil
+	return podStatus(pod), nil
 }
 
-// extractPodVersion extracts the version from the pod's containers.
-func extractPodVersion(pod *corev1.Pod) (string, error) {
-	for _, container := range pod.Spec.Containers {
-		// Assuming the version is included in the image name (e.g., "grafana/loki:2.6.1")
-		parts := strings.Split(container.Image, ":")
-		if len(parts) == 2 {
-			return parts[1], nil // Return version if found in image name.
+// podStatus returns the waiting reason of a container, e.g. CrashLoopBackOff,
+// or the pod phase if no container is waiting.
+func podStatus(pod *corev1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" {
+			return containerStatus.State.Waiting.Reason
 		}
 	}
-	return "", fmt.Errorf("version not found")
+	return string(pod.Status.Phase)
 }
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Limits of the /logs command.
+const (
+	defaultLogTailLines = 100
+	maxLogTailLines     = 2000
+	// maxInlineLogBytes is the largest log posted as a message; larger logs are uploaded as a file.
+	maxInlineLogBytes = 3000
+	// maxLogBytesPerPod caps how much of each pod's log is read.
+	maxLogBytesPerPod = 1 << 20
+)
+
+// defaultRedactPatterns hide common credentials when no patterns are configured.
+var defaultRedactPatterns = []string{
+	`(?i)(password|passwd|secret|token|api[_-]?key)\s*[:=]\s*\S+`,
+	`(?i)bearer\s+[a-z0-9._~+/=-]+`,
+}
+
+// LogsConfig tunes the /logs command.
+type LogsConfig struct {
+	// Redact lists regular expressions whose matches are replaced with [REDACTED]
+	// before logs are posted. Defaults to patterns for passwords, tokens and keys.
+	Redact []string `yaml:"redact"`
+
+	redactPatterns []*regexp.Regexp
+}
+
+// compile checks the redact patterns, falling back to the defaults.
+func (c *LogsConfig) compile() error {
+	if len(c.Redact) == 0 {
+		c.Redact = defaultRedactPatterns
+	}
+	c.redactPatterns = nil
+	for _, pattern := range c.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("logs redact pattern %q: %w", pattern, err)
+		}
+		c.redactPatterns = append(c.redactPatterns, re)
+	}
+	return nil
+}
+
+// redact replaces every match of the configured patterns in text.
+func (c *LogsConfig) redact(text string) string {
+	for _, re := range c.redactPatterns {
+		text = re.ReplaceAllString(text, "[REDACTED]")
+	}
+	return text
+}
+
+// logsOptions are the flags of the /logs command.
+type logsOptions struct {
+	previous  bool
+	tailLines int64
+	container string
+}
+
+// parseLogsOptions parses [--previous] [--tail N] [--container c].
+func parseLogsOptions(args []string) (logsOptions, error) {
+	opts := logsOptions{tailLines: defaultLogTailLines}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--previous":
+			opts.previous = true
+		case "--tail":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--tail needs a number of lines")
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || n <= 0 || n > maxLogTailLines {
+				return opts, fmt.Errorf("--tail must be a number between 1 and %d", maxLogTailLines)
+			}
+			opts.tailLines = n
+		case "--container":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--container needs a container name")
+			}
+			i++
+			opts.container = args[i]
+		default:
+			return opts, fmt.Errorf("unknown option %s", args[i])
+		}
+	}
+	return opts, nil
+}
+
+// handleLogsCommand posts the recent logs of the pods of an app, uploading them
+// as a file when they are too long for a message.
+func handleLogsCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/logs").Inc()
+	commandText := command.Command + " " + command.Text
+	usage := "Invalid command format. Expected format: /logs <namespace> <label> [--previous] [--tail N] [--container name]"
+
+	parts := strings.Fields(command.Text)
+	if len(parts) < 2 {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, usage)
+	}
+	opts, err := parseLogsOptions(parts[2:])
+	if err != nil {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("%s. %s", err, usage))
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("logs") {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for reading logs. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("logs")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "logs"); err != nil {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	label := parts[1]
+	namespace := env.Namespace
+
+	pods, err := listPods(namespace, appSelector(label))
+	if err != nil {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to list pods of `%s` in namespace `%s`: %s", label, namespace, err))
+	}
+	if len(pods) == 0 {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("No pods with label `%s` found in namespace `%s`.", label, namespace))
+	}
+
+	// Default to the primary container of the app so that sidecar logs are not picked up
+	if opts.container == "" {
+		if app := botConfig.app(label); app != nil {
+			opts.container = app.Container
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, pod := range pods {
+		logs, err := readPodLogs(pod, opts)
+		fmt.Fprintf(&buf, "==> %s <==\n", pod.Name)
+		if err != nil {
+			fmt.Fprintf(&buf, "Failed to read logs: %s\n", err)
+			continue
+		}
+		buf.WriteString(logs)
+		if !strings.HasSuffix(logs, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	logs := botConfig.Logs.redact(buf.String())
+
+	if len(logs) <= maxInlineLogBytes {
+		return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Logs of `%s` in namespace `%s`:\n```\n%s```", label, namespace, logs))
+	}
+
+	_, err = client.UploadFileV2(slack.UploadFileV2Parameters{
+		Channel:        command.ChannelID,
+		Content:        logs,
+		FileSize:       len(logs),
+		Filename:       fmt.Sprintf("%s-%s.log", namespace, label),
+		Title:          fmt.Sprintf("Logs of %s in %s", label, namespace),
+		InitialComment: fmt.Sprintf("Last %d lines of each pod of `%s` in namespace `%s`.", opts.tailLines, label, namespace),
+	})
+	if err != nil {
+		totalErrors.WithLabelValues("/logs").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to upload logs: %s", err))
+	}
+	return nil, nil
+}
+
+// readPodLogs returns the last lines of the log of a container in the pod.
+func readPodLogs(pod *corev1.Pod, opts logsOptions) (string, error) {
+	container := opts.container
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	limitBytes := int64(maxLogBytesPerPod)
+	request := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   opts.previous,
+		TailLines:  &opts.tailLines,
+		LimitBytes: &limitBytes,
+	})
+	stream, err := request.Stream(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to stream logs of container %s: %w", container, err)
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of container %s: %w", container, err)
+	}
+	return string(data), nil
+}
@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"log"
-	"net/http"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log"
+	"net/http"
 )
 
 // Оголошення кастомних метрик
@@ -23,12 +23,26 @@ var (
 		},
 		[]string{"path"},
 	)
+	activeWatches = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "slackbot_active_watches",
+			Help: "Number of rollouts currently watched by the Slack bot.",
+		},
+	)
+	activeAlerts = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "slackbot_active_alerts",
+			Help: "Number of pod health problems currently reported by the Slack bot.",
+		},
+	)
 )
 
 func init() {
 	// Реєстрація кастомних метрик у реєстрі Prometheus
 	prometheus.MustRegister(totalRequests)
 	prometheus.MustRegister(totalErrors)
+	prometheus.MustRegister(activeWatches)
+	prometheus.MustRegister(activeAlerts)
 }
 
 func startMetricsServer() {
@@ -41,4 +55,4 @@ func startMetricsServer() {
 	if err := http.ListenAndServe(port, nil); err != nil {
 		log.Fatalf("Failed to start metrics server: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the versioned schema migrations, one directory per SQL dialect.
+// Files are named <version>_<name>.sql and are applied in version order.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migration is a single forward schema migration.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// MigrationState reports whether a schema migration has been applied.
+type MigrationState struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// loadMigrations reads the embedded migrations of the given dialect directory, sorted by version.
+func loadMigrations(dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, path.Join("migrations", dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s migrations: %w", dir, err)
+	}
+
+	var migrations []migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>.sql", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		content, err := fs.ReadFile(migrationFiles, path.Join("migrations", dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// appliedMigrations returns the applied migration versions and when they were applied.
+func (s *sqlStore) appliedMigrations(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}) (map[int]time.Time, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations row: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Applies the pending schema migrations, each one in its own transaction.
+func (s *sqlStore) Migrate() error {
+	ctx := context.Background()
+
+	migrations, err := loadMigrations(s.dialect.migrations)
+	if err != nil {
+		return err
+	}
+
+	// Use a single connection, so the advisory lock and the migrations share a session
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if s.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, s.dialect.lock); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(ctx, s.dialect.unlock); err != nil {
+				log.Printf("Failed to release migration lock: %v", err)
+			}
+		}()
+	}
+
+	if _, err := conn.ExecContext(ctx, s.dialect.createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := s.appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %04d_%s", m.version, m.name)
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start migration %04d_%s: %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %04d_%s: %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind("INSERT INTO schema_migrations (version, name) VALUES (?, ?);"), m.version, m.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %04d_%s: %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Reports every embedded migration and whether it has been applied, without applying anything.
+func (s *sqlStore) MigrationStatus() ([]MigrationState, error) {
+	ctx := context.Background()
+
+	migrations, err := loadMigrations(s.dialect.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	// A database that was never migrated has no schema_migrations table yet, and every migration is pending
+	var tables int
+	if err := s.db.QueryRowContext(ctx, s.dialect.migrationsTableExists).Scan(&tables); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations table: %w", err)
+	}
+	applied := make(map[int]time.Time)
+	if tables > 0 {
+		if applied, err = s.appliedMigrations(ctx, s.db); err != nil {
+			return nil, err
+		}
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		states = append(states, MigrationState{Version: m.version, Name: m.name, Applied: ok, AppliedAt: appliedAt})
+	}
+	return states, nil
+}
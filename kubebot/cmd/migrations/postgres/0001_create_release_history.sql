@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS release_history (
+	id BIGSERIAL PRIMARY KEY,
+	namespace TEXT,
+	version TEXT,
+	label TEXT,
+	release_time TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE release_history ADD COLUMN action TEXT NOT NULL DEFAULT 'release';
+ALTER TABLE release_history ADD COLUMN actor TEXT NOT NULL DEFAULT '';
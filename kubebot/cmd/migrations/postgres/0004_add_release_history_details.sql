@@ -0,0 +1 @@
+ALTER TABLE release_history ADD COLUMN details TEXT NOT NULL DEFAULT '';
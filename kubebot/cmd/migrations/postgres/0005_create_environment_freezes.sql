@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS environment_freezes (
+	id BIGSERIAL PRIMARY KEY,
+	environment TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	frozen_by TEXT NOT NULL,
+	channel_id TEXT NOT NULL DEFAULT '',
+	suspended TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ,
+	lifted_by TEXT NOT NULL DEFAULT '',
+	lifted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS environment_freezes_environment_lifted_at ON environment_freezes (environment, lifted_at);
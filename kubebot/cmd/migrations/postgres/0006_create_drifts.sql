@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS drifts (
+	environment TEXT NOT NULL,
+	label TEXT NOT NULL,
+	desired_version TEXT NOT NULL,
+	running_version TEXT NOT NULL,
+	detected_at TIMESTAMPTZ NOT NULL,
+	reported BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (environment, label)
+);
@@ -0,0 +1 @@
+ALTER TABLE approval_requests ADD COLUMN force BOOLEAN NOT NULL DEFAULT FALSE;
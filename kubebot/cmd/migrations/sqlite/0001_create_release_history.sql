@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS release_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	namespace TEXT,
+	version TEXT,
+	label TEXT,
+	release_time DATETIME DEFAULT CURRENT_TIMESTAMP
+);
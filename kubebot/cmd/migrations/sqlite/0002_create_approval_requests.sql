@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS approval_requests (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	environment TEXT NOT NULL,
+	label TEXT NOT NULL,
+	version TEXT NOT NULL,
+	requested_by TEXT NOT NULL,
+	channel_id TEXT NOT NULL,
+	message_ts TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	decided_by TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL,
+	decided_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS approval_requests_status_expires_at ON approval_requests (status, expires_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS environment_freezes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	environment TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	frozen_by TEXT NOT NULL,
+	channel_id TEXT NOT NULL DEFAULT '',
+	suspended TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME,
+	lifted_by TEXT NOT NULL DEFAULT '',
+	lifted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS environment_freezes_environment_lifted_at ON environment_freezes (environment, lifted_at);
@@ -0,0 +1,5 @@
+-- Lift all but the latest of the freezes created concurrently before the index existed
+UPDATE environment_freezes SET lifted_at = CURRENT_TIMESTAMP
+WHERE lifted_at IS NULL AND id NOT IN (SELECT MAX(id) FROM environment_freezes WHERE lifted_at IS NULL GROUP BY environment);
+
+CREATE UNIQUE INDEX IF NOT EXISTS environment_freezes_active ON environment_freezes (environment) WHERE lifted_at IS NULL;
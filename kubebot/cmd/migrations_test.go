@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{name: "sqlite", dir: "sqlite"},
+		{name: "postgres", dir: "postgres"},
+		{name: "unknown dialect", dir: "oracle", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.dir)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("loadMigrations() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) == 0 {
+				t.Fatal("loadMigrations() found no migrations")
+			}
+			for i, m := range migrations {
+				if m.version != i+1 {
+					t.Errorf("migration #%d has version %d, want versions to be sorted and without gaps", i+1, m.version)
+				}
+				if m.name == "" || m.sql == "" {
+					t.Errorf("migration %d has no name or SQL", m.version)
+				}
+			}
+		})
+	}
+
+	// Both dialects evolve the same schema, so they must define the same migrations
+	sqlite, _ := loadMigrations("sqlite")
+	postgres, _ := loadMigrations("postgres")
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("sqlite has %d migrations, postgres has %d", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].name != postgres[i].name {
+			t.Errorf("migration %d is %s for sqlite but %s for postgres", sqlite[i].version, sqlite[i].name, postgres[i].name)
+		}
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	store, err := openSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, migrate := range []bool{false, true} {
+		if migrate {
+			if err := store.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		states, err := store.MigrationStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, state := range states {
+			if state.Applied != migrate {
+				t.Errorf("after Migrate() = %v, migration %d applied = %v", migrate, state.Version, state.Applied)
+			}
+		}
+		if !migrate {
+			var tables int
+			if err := store.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table';").Scan(&tables); err != nil {
+				t.Fatal(err)
+			}
+			if tables != 0 {
+				t.Errorf("MigrationStatus() created %d tables, want none", tables)
+			}
+		}
+	}
+}
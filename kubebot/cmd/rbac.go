@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is the level of access a Slack user has in an environment. Each role
+// includes the permissions of the roles below it.
+type Role int
+
+const (
+	roleNone Role = iota
+	roleViewer
+	roleDeployer
+	roleApprover
+	roleAdmin
+)
+
+var roleNames = map[Role]string{
+	roleNone:     "none",
+	roleViewer:   "viewer",
+	roleDeployer: "deployer",
+	roleApprover: "approver",
+	roleAdmin:    "admin",
+}
+
+// defaultCommandRoles is the minimum role needed to run each command when the
+// environment doesn't override it.
+var defaultCommandRoles = map[string]Role{
+	"list":      roleViewer,
+	"diff":      roleViewer,
+	"logs":      roleViewer,
+	"events":    roleViewer,
+	"describe":  roleViewer,
+	"flux":      roleViewer,
+	"drift":     roleViewer,
+	"promote":   roleDeployer,
+	"rollback":  roleDeployer,
+	"reconcile": roleDeployer,
+	"restart":   roleDeployer,
+	"scale":     roleDeployer,
+	"approve":   roleApprover,
+	"freeze":    roleApprover,
+	"unfreeze":  roleApprover,
+}
+
+// RBACConfig maps Slack user IDs to roles.
+type RBACConfig struct {
+	// Enabled turns on the role checks. Defaults to true; when explicitly
+	// disabled every user is an admin.
+	Enabled *bool `yaml:"enabled"`
+	// DefaultRole is the role of users that are not listed. Defaults to viewer.
+	DefaultRole string `yaml:"defaultRole"`
+	// Users maps Slack user IDs to their role in every environment.
+	Users map[string]string `yaml:"users"`
+}
+
+// enabled reports whether the role checks are on, failing closed when the
+// config doesn't say.
+func (c RBACConfig) enabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// String returns the config name of the role.
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// parseRole converts a role name from the config into a Role.
+func parseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if strings.EqualFold(name, roleName) {
+			return role, nil
+		}
+	}
+	return roleNone, fmt.Errorf("unknown role %q, expected one of none, viewer, deployer, approver, admin", name)
+}
+
+// validateRoles checks that every role name in the map is known.
+func validateRoles(roles map[string]string, where string) error {
+	for key, name := range roles {
+		if _, err := parseRole(name); err != nil {
+			return fmt.Errorf("%s %s: %w", where, key, err)
+		}
+	}
+	return nil
+}
+
+// roleOf returns the role of the Slack user in the environment. Environment
+// level user roles take precedence over the global ones.
+func (c *Config) roleOf(userID string, env *Environment) Role {
+	if !c.RBAC.enabled() {
+		return roleAdmin
+	}
+
+	name, ok := env.Users[userID]
+	if !ok {
+		name, ok = c.RBAC.Users[userID]
+	}
+	if !ok {
+		name = c.RBAC.DefaultRole
+	}
+
+	role, err := parseRole(name)
+	if err != nil {
+		return roleNone
+	}
+	return role
+}
+
+// requiredRole returns the minimum role needed to run the command in the environment.
+func (e *Environment) requiredRole(command string) Role {
+	command = strings.TrimPrefix(command, "/")
+	if name, ok := e.Roles[command]; ok {
+		if role, err := parseRole(name); err == nil {
+			return role
+		}
+	}
+	if role, ok := defaultCommandRoles[command]; ok {
+		return role
+	}
+	return roleAdmin
+}
+
+// authorize returns an error describing why the Slack user may not run the
+// command in the environment, or nil if the user is allowed to.
+func (c *Config) authorize(userID string, env *Environment, command string) error {
+	role := c.roleOf(userID, env)
+	required := env.requiredRole(command)
+	if role < required {
+		return fmt.Errorf("`/%s` in `%s` requires the `%s` role, your role is `%s`", strings.TrimPrefix(command, "/"), env.Name, required, role)
+	}
+	return nil
+}
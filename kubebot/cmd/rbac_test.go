@@ -0,0 +1,57 @@
+package cmd
+
+import "testing"
+
+func TestConfigAuthorize(t *testing.T) {
+	// Enabled is left unset, which turns the role checks on
+	c := &Config{
+		RBAC: RBACConfig{
+			DefaultRole: "viewer",
+			Users:       map[string]string{"UDEPLOYER": "deployer", "UAPPROVER": "approver", "UADMIN": "admin", "UBROKEN": "owner"},
+		},
+	}
+	qa := &Environment{Name: "qa"}
+	prod := &Environment{
+		Name:  "prod",
+		Roles: map[string]string{"promote": "approver"},
+		Users: map[string]string{"UDEPLOYER": "viewer", "UGUEST": "approver"},
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		env     *Environment
+		command string
+		allowed bool
+	}{
+		{name: "default role may view", userID: "UNKNOWN", env: qa, command: "list", allowed: true},
+		{name: "default role may not deploy", userID: "UNKNOWN", env: qa, command: "promote"},
+		{name: "leading slash is ignored", userID: "UNKNOWN", env: qa, command: "/list", allowed: true},
+		{name: "deployer may promote", userID: "UDEPLOYER", env: qa, command: "promote", allowed: true},
+		{name: "deployer may not freeze", userID: "UDEPLOYER", env: qa, command: "freeze"},
+		{name: "approver may freeze", userID: "UAPPROVER", env: qa, command: "freeze", allowed: true},
+		{name: "environment role override", userID: "UAPPROVER", env: prod, command: "promote", allowed: true},
+		{name: "environment user demoted", userID: "UDEPLOYER", env: prod, command: "restart"},
+		{name: "environment user promoted", userID: "UGUEST", env: prod, command: "approve", allowed: true},
+		{name: "unknown command needs admin", userID: "UAPPROVER", env: qa, command: "selfdestruct"},
+		{name: "admin may run unknown commands", userID: "UADMIN", env: qa, command: "selfdestruct", allowed: true},
+		{name: "unknown role has no access", userID: "UBROKEN", env: qa, command: "list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.authorize(tt.userID, tt.env, tt.command)
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("authorize(%s, %s, %s) = %v, want allowed = %v", tt.userID, tt.env.Name, tt.command, err, tt.allowed)
+			}
+		})
+	}
+
+	t.Run("explicitly disabled RBAC allows everything", func(t *testing.T) {
+		enabled := false
+		disabled := &Config{RBAC: RBACConfig{Enabled: &enabled, DefaultRole: "viewer"}}
+		if err := disabled.authorize("UNKNOWN", prod, "selfdestruct"); err != nil {
+			t.Errorf("authorize() = %v, want nil", err)
+		}
+	})
+}
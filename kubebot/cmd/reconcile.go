@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileRequestedAtAnnotation makes Flux reconcile an object out of its
+// interval whenever the value changes, the way `flux reconcile` does.
+const reconcileRequestedAtAnnotation = "reconcile.fluxcd.io/requestedAt"
+
+// reconcileKinds maps the kinds /reconcile accepts to the Flux kind they reconcile.
+var reconcileKinds = map[string]fluxKind{
+	"source":        fluxGitRepository,
+	"kustomization": fluxKustomization,
+	"image":         fluxImageRepository,
+}
+
+// reconcileKindNames lists the keys of reconcileKinds for user facing messages.
+const reconcileKindNames = "source, kustomization or image"
+
+// FluxReference names a Flux object reconciled after a version is committed.
+type FluxReference struct {
+	// Kind is source (GitRepository), kustomization or image (ImageRepository).
+	Kind string `yaml:"kind"`
+	// Name is the name of the object.
+	Name string `yaml:"name"`
+	// Namespace is the namespace of the object. Defaults to the namespace of the environment.
+	Namespace string `yaml:"namespace"`
+}
+
+// handleReconcileCommand asks Flux to reconcile a GitRepository, Kustomization
+// or ImageRepository now instead of at its next interval.
+func handleReconcileCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/reconcile").Inc()
+	commandText := command.Command + " " + command.Text
+	usage := "Invalid command format. Expected format: /reconcile <namespace> [source|kustomization|image] <name>"
+
+	parts := strings.Fields(command.Text)
+	if len(parts) < 2 || len(parts) > 3 {
+		totalErrors.WithLabelValues("/reconcile").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, usage)
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("reconcile") {
+		totalErrors.WithLabelValues("/reconcile").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for reconciliation. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("reconcile")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "reconcile"); err != nil {
+		totalErrors.WithLabelValues("/reconcile").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	namespace := env.Namespace
+
+	// The kind may be left out, as Kustomizations are what is reconciled most often
+	ref := FluxReference{Kind: "kustomization", Name: parts[len(parts)-1], Namespace: namespace}
+	if len(parts) == 3 {
+		ref.Kind = parts[1]
+	}
+	kind, ok := reconcileKinds[ref.Kind]
+	if !ok {
+		totalErrors.WithLabelValues("/reconcile").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Unknown kind `%s`, expected %s. %s", ref.Kind, reconcileKindNames, usage))
+	}
+
+	if err := reconcileFluxObject(context.TODO(), kind, namespace, ref.Name); err != nil {
+		totalErrors.WithLabelValues("/reconcile").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to reconcile %s `%s` in namespace `%s`: %s", kind.Kind, ref.Name, namespace, err))
+	}
+
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Reconciliation of %s `%s` in namespace `%s` has been requested. Use `/flux %s` to follow its status.", kind.Kind, ref.Name, namespace, env.Name))
+}
+
+// reconcileFluxObject sets the requestedAt annotation of a Flux object to the
+// current time, so that its controller reconciles it right away.
+func reconcileFluxObject(ctx context.Context, kind fluxKind, namespace, name string) error {
+	mapping, err := restMapper.RESTMapping(kind.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return fmt.Errorf("%s is not installed in the cluster", kind.Kind)
+		}
+		return fmt.Errorf("failed to resolve %s: %w", kind.Kind, err)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				reconcileRequestedAtAnnotation: time.Now().Format(time.RFC3339Nano),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build reconcile patch: %w", err)
+	}
+
+	_, err = dynamicClient.Resource(mapping.Resource).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s %s not found", kind.Kind, name)
+	}
+	return err
+}
+
+// reconcileAfterCommit reconciles the Flux objects configured for the app, so
+// that a version committed to the GitOps repository is applied without
+// waiting for their interval. Objects are reconciled in the configured order
+// and the first failure is returned.
+func reconcileAfterCommit(app *App, env *Environment) error {
+	for _, ref := range app.Reconcile {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = env.Namespace
+		}
+		if err := reconcileFluxObject(context.TODO(), reconcileKinds[ref.Kind], namespace, ref.Name); err != nil {
+			return fmt.Errorf("failed to reconcile %s %s/%s: %w", reconcileKinds[ref.Kind].Kind, namespace, ref.Name, err)
+		}
+	}
+	return nil
+}
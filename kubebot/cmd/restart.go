@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation is the pod template annotation `kubectl rollout restart` sets.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// handleRestartCommand restarts the pods of an app the way `kubectl rollout
+// restart` does, records the restart in the history and watches the rollout.
+func handleRestartCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/restart").Inc()
+	commandText := command.Command + " " + command.Text
+
+	parts := strings.Fields(command.Text)
+	if len(parts) != 2 {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, "Invalid command format. Expected format: /restart <namespace> <label>")
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("restart") {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for restarts. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("restart")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "restart"); err != nil {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	label := parts[1]
+	namespace := env.Namespace
+
+	deployment, err := findDeploymentForApp(namespace, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to restart `%s` in namespace `%s`: %s", label, namespace, err))
+	}
+	version, err := appVersion(deployment.Spec.Template.Spec, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to determine the version of deployment `%s`: %s", deployment.Name, err))
+	}
+
+	if err := restartDeployment(namespace, deployment.Name); err != nil {
+		totalErrors.WithLabelValues("/restart").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to restart deployment `%s` in namespace `%s`: %s", deployment.Name, namespace, err))
+	}
+
+	go trackRollout(namespace, label, version, commandText, client, command.ChannelID, command.UserID)
+
+	if err := store.AddReleaseHistory(Release{Namespace: namespace, Version: version, Label: label, Action: historyRestart, Actor: command.UserID}); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to record the restart in the history: %s", err))
+	}
+
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Restart of deployment `%s` with version `%s` in namespace `%s` has been initiated. Please wait for the rollout to complete.", deployment.Name, version, namespace))
+}
+
+// restartDeployment sets the restartedAt annotation of the pod template to the
+// current time, so that the Deployment replaces all of its pods.
+func restartDeployment(namespace, name string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build restart patch: %w", err)
+	}
+
+	_, err = clientset.AppsV1().Deployments(namespace).Patch(context.TODO(), name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
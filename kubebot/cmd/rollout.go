@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// RolloutConfig bounds how long rollouts are watched after a promotion or rollback.
+type RolloutConfig struct {
+	// Timeout is how long to wait for a rollout to complete. Defaults to 15 minutes.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// progressRefreshInterval is how often the progress message of a rollout is
+// refreshed while the deployment itself does not change.
+const progressRefreshInterval = 15 * time.Second
+
+// rolloutState summarizes the rollout of a Deployment at one point in time.
+type rolloutState struct {
+	// done is true once every replica runs the new pod template and is available.
+	done bool
+	// failed is true when the Deployment exceeded its progress deadline.
+	failed bool
+	// message describes the progress, e.g. "3/5 ready, 4/5 updated".
+	message string
+}
+
+// findDeploymentForApp returns the Deployment whose pods carry the given app.kubernetes.io/name label.
+func findDeploymentForApp(namespace, label string) (*appsv1.Deployment, error) {
+	deployments, err := listDeployments(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments in namespace %s: %w", namespace, err)
+	}
+
+	for _, deployment := range deployments {
+		if deployment.Spec.Template.Labels["app.kubernetes.io/name"] == label {
+			return deployment, nil
+		}
+	}
+	return nil, fmt.Errorf("no deployment for app %s found in namespace %s", label, namespace)
+}
+
+// deploymentRolloutState evaluates the rollout of the Deployment the same way
+// `kubectl rollout status` does.
+func deploymentRolloutState(d *appsv1.Deployment) rolloutState {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	status := d.Status
+	progress := fmt.Sprintf("%d/%d ready, %d/%d updated", status.ReadyReplicas, desired, status.UpdatedReplicas, desired)
+
+	// Conditions describe the previous spec until the controller observes the current one
+	if status.ObservedGeneration < d.Generation {
+		return rolloutState{message: "waiting for the deployment spec update to be observed"}
+	}
+
+	for _, condition := range status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return rolloutState{failed: true, message: fmt.Sprintf("%s. Progress deadline exceeded: %s", progress, condition.Message)}
+		}
+	}
+
+	switch {
+	case status.UpdatedReplicas < desired:
+		return rolloutState{message: progress}
+	case status.Replicas > status.UpdatedReplicas:
+		return rolloutState{message: fmt.Sprintf("%s, %d old replicas pending termination", progress, status.Replicas-status.UpdatedReplicas)}
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return rolloutState{message: progress}
+	}
+	return rolloutState{done: true, message: progress}
+}
+
+// trackRollout follows the Deployment of the app until its pod template runs the
+// target version and the rollout completes or fails, keeping a progress message
+// in Slack up to date. The watch gives up when the configured rollout timeout
+// passes or the bot shuts down, reporting the last observed pod states. It
+// reports whether the rollout completed.
+func trackRollout(namespace, label, targetVersion string, command string, client *slack.Client, channelID, userID string) bool {
+	activeWatches.Inc()
+	defer activeWatches.Dec()
+
+	ctx, cancel := context.WithTimeout(lifecycleCtx, botConfig.Rollout.Timeout)
+	defer cancel()
+
+	deployment, err := findDeploymentForApp(namespace, label)
+	if err != nil {
+		log.Printf("Failed to find deployment of %s in namespace `%s`: %v", label, namespace, err)
+		sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Failed to track the rollout of `%s` in namespace `%s`: %s", label, namespace, err))
+		return false
+	}
+
+	// The progress message also shows the Flux objects of the app, refreshed
+	// periodically since Flux progress does not change the deployment
+	progressText := fmt.Sprintf("Rollout of `%s` version `%s` in namespace `%s`: waiting for the new version to be applied.", deployment.Name, targetVersion, namespace)
+	progressTS := postProgressMessage(client, channelID, command, withFluxSummary(progressText, namespace, label))
+	refresh := time.NewTicker(progressRefreshInterval)
+	defer refresh.Stop()
+	lastMessage := ""
+
+	for {
+		// create watcher for the deployment; it is recreated when the API server closes it
+		watcher, err := clientset.AppsV1().Deployments(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", deployment.Name).String(),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				reportRolloutTimeout(ctx, namespace, label, targetVersion, command, client, channelID, userID)
+				return false
+			}
+			log.Printf("Failed to watch deployment `%s` in namespace `%s`: %v", deployment.Name, namespace, err)
+			sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Failed to watch deployment `%s` in namespace `%s`", deployment.Name, namespace))
+			return false
+		}
+
+	events:
+		for {
+			select {
+			case <-ctx.Done():
+				watcher.Stop()
+				reportRolloutTimeout(ctx, namespace, label, targetVersion, command, client, channelID, userID)
+				return false
+			case <-refresh.C:
+				updateProgressMessage(client, channelID, progressTS, command, withFluxSummary(progressText, namespace, label))
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					break events
+				}
+				if event.Type == watch.Deleted {
+					watcher.Stop()
+					sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Deployment `%s` in namespace `%s` was deleted during the rollout.", deployment.Name, namespace))
+					return false
+				}
+				d, ok := event.Object.(*appsv1.Deployment)
+				if !ok {
+					continue
+				}
+
+				// Until Flux applies the change, the pod template still references the previous version
+				if version, err := appVersion(d.Spec.Template.Spec, label); err != nil || version != targetVersion {
+					continue
+				}
+
+				state := deploymentRolloutState(d)
+				if state.message != lastMessage {
+					lastMessage = state.message
+					progressText = fmt.Sprintf("Rollout of `%s` version `%s` in namespace `%s`: %s.", d.Name, targetVersion, namespace, state.message)
+					updateProgressMessage(client, channelID, progressTS, command, withFluxSummary(progressText, namespace, label))
+				}
+
+				if state.done {
+					watcher.Stop()
+					sendSuccessMessage(client, channelID, userID, command, fmt.Sprintf("Deployment `%s` with version `%s` in namespace `%s` has been successfully rolled out: %s.", d.Name, targetVersion, namespace, state.message))
+					return true
+				}
+				if state.failed {
+					watcher.Stop()
+					sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Rollout of deployment `%s` with version `%s` in namespace `%s` has failed: %s.", d.Name, targetVersion, namespace, state.message))
+					return false
+				}
+			}
+		}
+		watcher.Stop()
+	}
+}
+
+// reportRolloutTimeout tells the user that the rollout watch stopped before
+// the rollout finished, along with the last observed states of the app's pods.
+func reportRolloutTimeout(ctx context.Context, namespace, label, targetVersion, command string, client *slack.Client, channelID, userID string) {
+	reason := fmt.Sprintf("did not complete within %s", botConfig.Rollout.Timeout)
+	if ctx.Err() == context.Canceled {
+		reason = "stopped being watched because the bot is shutting down"
+	}
+
+	pods, err := listPods(namespace, appSelector(label))
+
+	var states []string
+	switch {
+	case err != nil:
+		states = append(states, fmt.Sprintf("Failed to get pod states: %s", err))
+	case len(pods) == 0:
+		states = append(states, "No pods found.")
+	default:
+		for _, pod := range pods {
+			states = append(states, describePodState(pod))
+		}
+	}
+
+	sendErrorMessage(client, channelID, userID, command, fmt.Sprintf("Rollout of `%s` version `%s` in namespace `%s` %s. Last observed pod states:\n%s", label, targetVersion, namespace, reason, strings.Join(states, "\n")))
+}
+
+// describePodState formats the version, phase or waiting reason, readiness and
+// restarts of a pod on a single line.
+func describePodState(pod *corev1.Pod) string {
+	version, _ := appVersion(pod.Spec, pod.Labels["app.kubernetes.io/name"])
+	ready, restarts := 0, int32(0)
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Ready {
+			ready++
+		}
+		restarts += containerStatus.RestartCount
+	}
+	return fmt.Sprintf("Pod: `%s`, Version: `%s`, Status: `%s`, Ready: `%d/%d`, Restarts: `%d`", pod.Name, version, podStatus(pod), ready, len(pod.Spec.Containers), restarts)
+}
+
+// withFluxSummary appends the status of the Flux objects of the app to a progress text.
+func withFluxSummary(text, namespace, label string) string {
+	if summary := fluxSummary(namespace, label); summary != "" {
+		return text + "\n" + summary
+	}
+	return text
+}
+
+// postProgressMessage posts a message that is later updated with the progress
+// of a long running operation and returns its timestamp.
+func postProgressMessage(client *slack.Client, channelID, command, text string) string {
+	_, ts, err := client.PostMessage(channelID, slack.MsgOptionAttachments(progressAttachment(command, text)))
+	if err != nil {
+		log.Printf("Failed to post progress message: %v", err)
+		return ""
+	}
+	return ts
+}
+
+// updateProgressMessage replaces the text of a message posted with postProgressMessage.
+func updateProgressMessage(client *slack.Client, channelID, ts, command, text string) {
+	if ts == "" {
+		return
+	}
+	if _, _, _, err := client.UpdateMessage(channelID, ts, slack.MsgOptionAttachments(progressAttachment(command, text))); err != nil {
+		log.Printf("Failed to update progress message: %v", err)
+	}
+}
+
+// progressAttachment formats a progress update like the success and error messages.
+func progressAttachment(command, text string) slack.Attachment {
+	return slack.Attachment{
+		Pretext: fmt.Sprintf("*Command:* `%s`", command),
+		Text:    fmt.Sprintf("Last update: %s\n%s", time.Now().Format("2006-01-02 15:04:05"), text),
+		Color:   "#439fe0", // Blue color indicates progress
+	}
+}
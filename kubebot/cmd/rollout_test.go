@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func TestDeploymentRolloutState(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name        string
+		generation  int64
+		status      appsv1.DeploymentStatus
+		wantDone    bool
+		wantFailed  bool
+		wantMessage string
+	}{
+		{
+			name:        "spec update not observed yet",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3},
+			wantMessage: "waiting for the deployment spec update to be observed",
+		},
+		{
+			name:        "replicas being updated",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 1, ReadyReplicas: 3, AvailableReplicas: 3},
+			wantMessage: "3/3 ready, 1/3 updated",
+		},
+		{
+			name:        "old replicas terminating",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3},
+			wantMessage: "1 old replicas pending termination",
+		},
+		{
+			name:        "updated replicas not available",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 2, AvailableReplicas: 2},
+			wantMessage: "2/3 ready, 3/3 updated",
+		},
+		{
+			name:        "complete",
+			generation:  2,
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3},
+			wantDone:    true,
+			wantMessage: "3/3 ready, 3/3 updated",
+		},
+		{
+			name:       "progress deadline exceeded",
+			generation: 2,
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 1, ReadyReplicas: 3, AvailableReplicas: 3,
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: "ReplicaSet kbot-2 has timed out progressing."}}},
+			wantFailed:  true,
+			wantMessage: "Progress deadline exceeded: ReplicaSet kbot-2 has timed out progressing.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: tt.status}
+			d.Generation = tt.generation
+
+			got := deploymentRolloutState(d)
+			if got.done != tt.wantDone || got.failed != tt.wantFailed {
+				t.Errorf("done, failed = %v, %v, want %v, %v", got.done, got.failed, tt.wantDone, tt.wantFailed)
+			}
+			if !strings.Contains(got.message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", got.message, tt.wantMessage)
+			}
+		})
+	}
+}
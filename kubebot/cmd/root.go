@@ -31,3 +31,7 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "path to the YAML config file describing environments (defaults to dev, qa, stage, prod)")
+}
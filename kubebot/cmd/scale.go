@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fluxOwnerLabels are set by Flux on the objects it applies from git, keyed by
+// the label prefix and mapped to the kind of the Flux object.
+var fluxOwnerLabels = map[string]string{
+	"helm.toolkit.fluxcd.io":      "HelmRelease",
+	"kustomize.toolkit.fluxcd.io": "Kustomization",
+}
+
+// ScaleConfig limits the replicas /scale may set in an environment.
+type ScaleConfig struct {
+	// MinReplicas is the fewest replicas allowed. Defaults to 1.
+	MinReplicas int32 `yaml:"minReplicas"`
+	// MaxReplicas is the most replicas allowed. Defaults to 10.
+	MaxReplicas int32 `yaml:"maxReplicas"`
+}
+
+// handleScaleCommand sets the replicas of an app, or the minimum and maximum
+// replicas of its HorizontalPodAutoscaler, within the limits of the environment.
+func handleScaleCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
+	totalRequests.WithLabelValues("/scale").Inc()
+	commandText := command.Command + " " + command.Text
+	usage := "Invalid command format. Expected format: /scale <namespace> <label> <replicas|min-max>"
+
+	parts := strings.Fields(command.Text)
+	if len(parts) != 3 {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, usage)
+	}
+
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("scale") {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Namespace `%s` is not allowed for scaling. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("scale")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "scale"); err != nil {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Access denied: %s.", err))
+	}
+	label := parts[1]
+	namespace := env.Namespace
+
+	minReplicas, maxReplicas, err := parseReplicas(parts[2])
+	if err != nil {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("%s. %s", err, usage))
+	}
+	for _, replicas := range []int32{minReplicas, maxReplicas} {
+		if replicas < env.Scale.MinReplicas || replicas > env.Scale.MaxReplicas {
+			totalErrors.WithLabelValues("/scale").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Replicas in namespace `%s` must be between %d and %d.", namespace, env.Scale.MinReplicas, env.Scale.MaxReplicas))
+		}
+	}
+
+	deployment, err := findDeploymentForApp(namespace, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to scale `%s` in namespace `%s`: %s", label, namespace, err))
+	}
+	version, err := appVersion(deployment.Spec.Template.Spec, label)
+	if err != nil {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to determine the version of deployment `%s`: %s", deployment.Name, err))
+	}
+
+	hpa, err := findHPAForDeployment(namespace, deployment.Name)
+	if err != nil {
+		totalErrors.WithLabelValues("/scale").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to look up the autoscaler of deployment `%s`: %s", deployment.Name, err))
+	}
+
+	var details, message string
+	if hpa != nil {
+		// A single number raises or lowers the floor of the autoscaler, keeping its
+		// ceiling when it is higher, but no higher than the limit of the environment
+		if minReplicas == maxReplicas && hpa.Spec.MaxReplicas > maxReplicas {
+			maxReplicas = hpa.Spec.MaxReplicas
+			if maxReplicas > env.Scale.MaxReplicas {
+				maxReplicas = env.Scale.MaxReplicas
+			}
+		}
+		currentMin := int32(1)
+		if hpa.Spec.MinReplicas != nil {
+			currentMin = *hpa.Spec.MinReplicas
+		}
+		if err := scaleHPA(namespace, hpa.Name, minReplicas, maxReplicas); err != nil {
+			totalErrors.WithLabelValues("/scale").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to scale autoscaler `%s` in namespace `%s`: %s", hpa.Name, namespace, err))
+		}
+		details = fmt.Sprintf("hpa %s min/max %d-%d -> %d-%d", hpa.Name, currentMin, hpa.Spec.MaxReplicas, minReplicas, maxReplicas)
+		message = fmt.Sprintf("Autoscaler `%s` of deployment `%s` in namespace `%s` now keeps between %d and %d replicas (was %d-%d).", hpa.Name, deployment.Name, namespace, minReplicas, maxReplicas, currentMin, hpa.Spec.MaxReplicas)
+	} else {
+		if minReplicas != maxReplicas {
+			totalErrors.WithLabelValues("/scale").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Deployment `%s` has no autoscaler, so a replica range cannot be set. Please pass a single number of replicas.", deployment.Name))
+		}
+		current := int32(1)
+		if deployment.Spec.Replicas != nil {
+			current = *deployment.Spec.Replicas
+		}
+		if err := scaleDeployment(namespace, deployment.Name, minReplicas); err != nil {
+			totalErrors.WithLabelValues("/scale").Inc()
+			return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("Failed to scale deployment `%s` in namespace `%s`: %s", deployment.Name, namespace, err))
+		}
+		details = fmt.Sprintf("replicas %d -> %d", current, minReplicas)
+		message = fmt.Sprintf("Deployment `%s` in namespace `%s` has been scaled from %d to %d replicas.", deployment.Name, namespace, current, minReplicas)
+	}
+
+	if owner := fluxOwner(deployment); owner != "" {
+		message += fmt.Sprintf("\n:warning: The deployment is managed by Flux (`%s`). If the replicas are set in git, Flux will revert this change on the next reconciliation.", owner)
+	}
+
+	if err := store.AddReleaseHistory(Release{Namespace: namespace, Version: version, Label: label, Action: historyScale, Actor: command.UserID, Details: details}); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, commandText, fmt.Sprintf("%s\nFailed to record the change in the history: %s", message, err))
+	}
+
+	return sendSuccessMessage(client, command.ChannelID, command.UserID, commandText, message)
+}
+
+// parseReplicas parses a number of replicas, e.g. 3, or a range, e.g. 2-5.
+func parseReplicas(value string) (int32, int32, error) {
+	minValue, maxValue, isRange := strings.Cut(value, "-")
+	minReplicas, err := strconv.ParseInt(minValue, 10, 32)
+	if err != nil || minReplicas < 0 {
+		return 0, 0, fmt.Errorf("invalid number of replicas %q", value)
+	}
+	if !isRange {
+		return int32(minReplicas), int32(minReplicas), nil
+	}
+	maxReplicas, err := strconv.ParseInt(maxValue, 10, 32)
+	if err != nil || maxReplicas < minReplicas {
+		return 0, 0, fmt.Errorf("invalid replica range %q", value)
+	}
+	return int32(minReplicas), int32(maxReplicas), nil
+}
+
+// findHPAForDeployment returns the HorizontalPodAutoscaler that scales the
+// named Deployment, or nil if there is none.
+func findHPAForDeployment(namespace, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpas, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range hpas.Items {
+		target := hpas.Items[i].Spec.ScaleTargetRef
+		if target.Kind == "Deployment" && target.Name == name {
+			return &hpas.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// scaleDeployment sets the replicas of the named Deployment.
+func scaleDeployment(namespace, name string, replicas int32) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": replicas},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build scale patch: %w", err)
+	}
+	_, err = clientset.AppsV1().Deployments(namespace).Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// scaleHPA sets the minimum and maximum replicas of the named HorizontalPodAutoscaler.
+func scaleHPA(namespace, name string, minReplicas, maxReplicas int32) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"minReplicas": minReplicas, "maxReplicas": maxReplicas},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build autoscaler patch: %w", err)
+	}
+	_, err = clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// fluxOwner returns the Flux HelmRelease or Kustomization that applied the
+// Deployment, or an empty string if Flux does not manage it.
+func fluxOwner(deployment *appsv1.Deployment) string {
+	for prefix, kind := range fluxOwnerLabels {
+		if name := deployment.Labels[prefix+"/name"]; name != "" {
+			return fmt.Sprintf("%s %s/%s", kind, deployment.Labels[prefix+"/namespace"], name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import "testing"
+
+func TestParseReplicas(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantMin int32
+		wantMax int32
+		wantErr bool
+	}{
+		{value: "3", wantMin: 3, wantMax: 3},
+		{value: "0", wantMin: 0, wantMax: 0},
+		{value: "2-5", wantMin: 2, wantMax: 5},
+		{value: "4-4", wantMin: 4, wantMax: 4},
+		{value: "", wantErr: true},
+		{value: "three", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "5-2", wantErr: true},
+		{value: "2-", wantErr: true},
+		{value: "2-x", wantErr: true},
+		{value: "99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			gotMin, gotMax, err := parseReplicas(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseReplicas(%q) = %d, %d, want an error", tt.value, gotMin, gotMax)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotMin != tt.wantMin || gotMax != tt.wantMax {
+				t.Errorf("parseReplicas(%q) = %d, %d, want %d, %d", tt.value, gotMin, gotMax, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -10,11 +11,6 @@ import (
 	"github.com/slack-go/slack/slackevents"
 )
 
-// allowedNamespaces defines the namespaces that are allowed for certain operations.
-var (
-	allowedNamespaces = map[string]bool{"qa": true, "stage": true, "prod": true}
-)
-
 // handleSlashCommand processes slash commands input by users in Slack.
 func handleSlashCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
 	switch command.Command {
@@ -30,6 +26,26 @@ func handleSlashCommand(command slack.SlashCommand, client *slack.Client) (inter
 		return handlePromoteCommand(command, client)
 	case "/rollback":
 		return handleRollbackCommand(command, client)
+	case "/logs":
+		return handleLogsCommand(command, client)
+	case "/events":
+		return handleEventsCommand(command, client)
+	case "/describe":
+		return handleDescribeCommand(command, client)
+	case "/flux":
+		return handleFluxCommand(command, client)
+	case "/drift":
+		return handleDriftCommand(command, client)
+	case "/reconcile":
+		return handleReconcileCommand(command, client)
+	case "/freeze":
+		return handleFreezeCommand(command, client)
+	case "/unfreeze":
+		return handleUnfreezeCommand(command, client)
+	case "/restart":
+		return handleRestartCommand(command, client)
+	case "/scale":
+		return handleScaleCommand(command, client)
 	default:
 		message := fmt.Sprintf("Current Date and Time: %s\nUnknown command: %s. Please use a supported command.", time.Now().Format("2006-01-02 15:04:05"), command.Command)
 		client.PostMessage(command.ChannelID, slack.MsgOptionText(message, false))
@@ -65,10 +81,20 @@ func handleHelpCommand(command slack.SlashCommand, client *slack.Client) (interf
 	commands := []string{
 		"/hello - Greet the bot",
 		"/help - Get this help message",
-		"/list <namespace> - List Kubernetes pods",
+		fmt.Sprintf("/list <namespace> [--all] - List Kubernetes pods, with --all every container image (%s)", botConfig.environmentNamesAllowing("list")),
 		"/diff <label> - Show differences in deployments",
-		"/promote <namespace> <label> - Promote a deployment to the next environment",
-		"/rollback <namespace> <label> - Rollback a deployment to the previous version",
+		fmt.Sprintf("/promote <namespace> <label> [--force] - Promote a deployment to the next environment, admins may --force it into a frozen one (%s)", botConfig.environmentNamesAllowing("promote")),
+		fmt.Sprintf("/rollback <namespace> <label> [--force] - Rollback a deployment to the previous version, admins may --force it in a frozen environment (%s)", botConfig.environmentNamesAllowing("rollback")),
+		fmt.Sprintf("/logs <namespace> <label> [--previous] [--tail N] [--container name] - Show recent pod logs (%s)", botConfig.environmentNamesAllowing("logs")),
+		fmt.Sprintf("/events <namespace> <label> - Show recent Kubernetes events of an app (%s)", botConfig.environmentNamesAllowing("events")),
+		fmt.Sprintf("/describe <namespace> <pod|label> - Describe a pod or the pods of an app (%s)", botConfig.environmentNamesAllowing("describe")),
+		fmt.Sprintf("/flux <namespace> [label] - Show the status of the Flux objects (%s)", botConfig.environmentNamesAllowing("flux")),
+		fmt.Sprintf("/drift [namespace] - Compare the versions in the GitOps repository with the running pods (%s)", botConfig.environmentNamesAllowing("drift")),
+		fmt.Sprintf("/reconcile <namespace> [source|kustomization|image] <name> - Make Flux reconcile an object now (%s)", botConfig.environmentNamesAllowing("reconcile")),
+		fmt.Sprintf("/freeze <namespace> [--for duration] [reason] - Stop promotions and rollbacks to an environment (%s)", botConfig.environmentNamesAllowing("freeze")),
+		fmt.Sprintf("/unfreeze <namespace> - Lift the freeze of an environment (%s)", botConfig.environmentNamesAllowing("unfreeze")),
+		fmt.Sprintf("/restart <namespace> <label> - Restart the pods of an app (%s)", botConfig.environmentNamesAllowing("restart")),
+		fmt.Sprintf("/scale <namespace> <label> <replicas|min-max> - Scale an app or its autoscaler (%s)", botConfig.environmentNamesAllowing("scale")),
 	}
 
 	message := fmt.Sprintf("Here are the commands you can use:\n```\n%s\n```", strings.Join(commands, "\n"))
@@ -91,22 +117,24 @@ func handleListPods(command slack.SlashCommand, client *slack.Client) (interface
 	// Increment total requests metric
 	totalRequests.WithLabelValues("/list").Inc()
 
-	// Define allowed namespace
-	allowedNamespaces := map[string]bool{"dev": true, "qa": true, "stage": true, "prod": true}
-
 	parts := strings.Fields(command.Text)
-	if len(parts) != 1 {
+	showContainers := len(parts) == 2 && parts[1] == "--all"
+	if len(parts) != 1 && !showContainers {
 		// Increment total errors metric
 		totalErrors.WithLabelValues("/list").Inc()
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /list <namespace>")
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /list <namespace> [--all]")
 	}
 
-	namespace := parts[0]
-
-	if !allowedNamespaces[namespace] {
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("list") {
+		totalErrors.WithLabelValues("/list").Inc()
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed for listing pods. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("list")))
+	}
+	if err := botConfig.authorize(command.UserID, env, "list"); err != nil {
 		totalErrors.WithLabelValues("/list").Inc()
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed for listing pods. Please choose from: dev, qa, stage, prod.", namespace))
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Access denied: %s.", err))
 	}
+	namespace := env.Namespace
 
 	// Get the list of pods in the specified namespace
 	podNames, versions, labelSelectors, err := getPodsInfoWithRetries(namespace, 3, 30*time.Second)
@@ -134,6 +162,13 @@ func handleListPods(command slack.SlashCommand, client *slack.Client) (interface
 		// Create a message for each pod with version, status, and labels
 		message := fmt.Sprintf("Pod: `%s`, Version: `%s`, Status: `%s`, Label: `%s`", podName, versions[i], status, labelSelectors[i])
 		messages = append(messages, message)
+
+		// With --all, list the image of every container in the pod, sidecars and init containers included
+		if showContainers {
+			if pod, err := getPod(namespace, podName); err == nil {
+				messages = append(messages, describeContainers(pod)...)
+			}
+		}
 	}
 
 	// Use sendSuccessMessage to send the pod information
@@ -150,8 +185,13 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 
 	label := parts[0] // Retrieve the label for version comparison
 
-	// Retrieve a list of namespaces to be checked
-	orderedNamespaces := []string{"dev", "qa", "stage", "prod"}
+	// Retrieve the environments to be checked, in pipeline order, skipping those the user may not view
+	var environments []*Environment
+	for _, env := range botConfig.environmentsAllowing("diff") {
+		if botConfig.authorize(command.UserID, env, "diff") == nil {
+			environments = append(environments, env)
+		}
+	}
 
 	// Create maps for versions and statuses in each namespace
 	versionMap := make(map[string]string)
@@ -160,7 +200,8 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 	// Check if at least one pod with the specified label is found
 	foundPodWithLabel := false
 
-	for _, ns := range orderedNamespaces {
+	for _, env := range environments {
+		ns := env.Namespace
 		podNames, versions, labelSelectors, err := getPodsInfoWithRetries(ns, 3, 30*time.Second)
 		if err != nil {
 			return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to get pod information in namespace %s: %s", ns, err))
@@ -178,8 +219,8 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 
 				// Add only the running pods
 				if status == "Running" {
-					versionMap[ns] = versions[i]
-					statusMap[ns] = status
+					versionMap[env.Name] = versions[i]
+					statusMap[env.Name] = status
 					break // Break the loop after finding the first running pod with the matching label
 				}
 			}
@@ -196,9 +237,9 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 	var allSameVersion = true
 	var firstVersion string
 
-	for _, ns := range orderedNamespaces {
-		version := versionMap[ns]
-		status := statusMap[ns]
+	for _, env := range environments {
+		version := versionMap[env.Name]
+		status := statusMap[env.Name]
 
 		if firstVersion == "" && version != "" {
 			firstVersion = version
@@ -207,7 +248,7 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 		}
 
 		if version != "" {
-			messages = append(messages, fmt.Sprintf("Namespace: `%s`, Version: `%s`, Status: `%s`", ns, version, status))
+			messages = append(messages, fmt.Sprintf("Namespace: `%s`, Version: `%s`, Status: `%s`", env.Namespace, version, status))
 		}
 	}
 
@@ -223,28 +264,38 @@ func handleDiffCommand(command slack.SlashCommand, client *slack.Client) (interf
 
 // handlePromoteCommand handles promotion of deployments to the next environment.
 func handlePromoteCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	parts := strings.Fields(command.Text)
+	parts, force := withoutForceFlag(strings.Fields(command.Text))
 	if len(parts) != 2 {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /promote <namespace> <label>")
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /promote <namespace> <label> [--force]")
 	}
 
-	namespace, label := parts[0], parts[1]
+	label := parts[1]
 
 	// Check if namespace is allowed for promotion
-	if !allowedNamespaces[namespace] {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed for promotion. Please choose from: qa, stage, prod.", namespace))
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("promote") {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed for promotion. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("promote")))
 	}
 
-	// Determine the source environment for the version
-	var sourceNamespace string
-	switch namespace {
-	case "qa":
-		sourceNamespace = "dev"
-	case "stage":
-		sourceNamespace = "qa"
-	case "prod":
-		sourceNamespace = "stage"
+	// Check if the user's role allows promotion to the namespace
+	if err := botConfig.authorize(command.UserID, env, "promote"); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Access denied: %s.", err))
+	}
+
+	// Check that the environment is not frozen, unless an admin overrides the freeze
+	if err := checkFreeze(env, command.UserID, "/promote", force); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("%s.", err))
 	}
+	namespace := env.Namespace
+
+	// Check if the app is registered, so the right GitOps files are updated
+	app := botConfig.app(label)
+	if app == nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("App `%s` is not registered for promotion. Please choose from: %s.", label, botConfig.appNames()))
+	}
+
+	// Determine the source environment for the version
+	sourceNamespace := botConfig.environment(env.Upstream).Namespace
 
 	// Retrieve the current version with the "Running" status and the specified label
 	var currentVersion string
@@ -287,38 +338,87 @@ func handlePromoteCommand(command slack.SlashCommand, client *slack.Client) (int
 		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Version `%s` is already deployed in namespace `%s`. No promotion needed.", currentVersion, namespace))
 	}
 
-	if err := checkReleaseHistoryTable(); err != nil {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to check release history table: %s", err.Error()))
+	// Promotions to environments that require approval wait for another user to approve them
+	if env.Approval.Required {
+		return requestPromotionApproval(env, label, versionToPromote, force, command, client)
 	}
 
+	return executePromotion(app, env, label, versionToPromote, command.Command+" "+command.Text, client, command.ChannelID, command.UserID)
+}
+
+// executePromotion updates the version in the GitOps repository, records the
+// release, watches the pods until the new version is running and soaks it.
+func executePromotion(app *App, env *Environment, label, versionToPromote, commandText string, client *slack.Client, channelID, userID string) (interface{}, error) {
+	namespace := env.Namespace
+
 	// Update the version in the GitHub file and deploy
-	err = updateVersionInGitHubFile(namespace, versionToPromote, fmt.Sprintf("Promote %s", label))
+	pr, err := updateVersionInGitHubFile(app, env, versionToPromote, fmt.Sprintf("Promote %s", label), userID)
+	if errors.Is(err, errAlreadyAtVersion) {
+		return sendSuccessMessage(client, channelID, userID, commandText, fmt.Sprintf("Version `%s` is already set for namespace `%s` in the GitOps repository. Nothing to promote.", versionToPromote, namespace))
+	}
 	if err != nil {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to promote version `%s` to namespace `%s`: %s", versionToPromote, namespace, err))
+		return sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("Failed to promote version `%s` to namespace `%s`: %s", versionToPromote, namespace, err))
+	}
+
+	// In the pull request mode, the release is recorded and watched only once the pull request is merged
+	if pr != nil {
+		go waitForPullRequestMerge(app, pr, commandText, client, channelID, userID, func() {
+			if err := reconcileAfterCommit(app, env); err != nil {
+				sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("The change will be applied at the next Flux interval: %s", err))
+			}
+			go watchPromotion(app, env, label, versionToPromote, commandText, client, channelID, userID)
+			if err := store.AddReleaseHistory(Release{Namespace: namespace, Version: versionToPromote, Label: label, Action: historyPromote, Actor: userID}); err != nil {
+				sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("Failed to record the promotion in the history: %s", err))
+			}
+		})
+		return sendSuccessMessage(client, channelID, userID, commandText, fmt.Sprintf("Pull request <%s|#%d> to promote version `%s` to namespace `%s` has been opened. The deployment will be watched once it is merged.", pr.GetHTMLURL(), pr.GetNumber(), versionToPromote, namespace))
+	}
+
+	message := fmt.Sprintf("Promotion of version `%s` to namespace `%s` has been initiated. Please wait for the deployment to complete.", versionToPromote, namespace)
+	if err := reconcileAfterCommit(app, env); err != nil {
+		message += fmt.Sprintf("\n:warning: The change will be applied at the next Flux interval: %s", err)
 	}
 
-	// Asynchronously check the status of pods after promotion
-	go checkPodStatusAfterPromotion(namespace, versionToPromote, command.Command+" "+command.Text, client, command.ChannelID, command.UserID)
+	// Asynchronously track the rollout of the deployment after promotion, then soak the new version
+	go watchPromotion(app, env, label, versionToPromote, commandText, client, channelID, userID)
 
-	if err := AddReleaseHistory(namespace, versionToPromote, label); err != nil {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Не вдалося додати історію релізу: %s", err.Error()))
+	if err := store.AddReleaseHistory(Release{Namespace: namespace, Version: versionToPromote, Label: label, Action: historyPromote, Actor: userID}); err != nil {
+		return sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("%s\nFailed to record the promotion in the history: %s", message, err))
 	}
 
-	return sendSuccessMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Promotion of version `%s` to namespace `%s` has been initiated. Please wait for the deployment to complete.", versionToPromote, namespace))
+	return sendSuccessMessage(client, channelID, userID, commandText, message)
 }
 
 // handleRollbackCommand handles rollback of deployments to a previous version.
 func handleRollbackCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	parts := strings.Fields(command.Text)
+	parts, force := withoutForceFlag(strings.Fields(command.Text))
 	if len(parts) != 2 { // Очікуємо два аргументи: namespace та label
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /rollback <namespace> <label>")
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "Invalid command format. Expected format: /rollback <namespace> <label> [--force]")
 	}
 
-	namespace, label := parts[0], parts[1]
+	label := parts[1]
 
 	// Checks if the namespace is permitted for rollback operations
-	if !allowedNamespaces[namespace] {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed. Please choose from: qa, stage, prod.", namespace))
+	env := botConfig.environment(parts[0])
+	if env == nil || !env.allows("rollback") {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Namespace `%s` is not allowed. Please choose from: %s.", parts[0], botConfig.environmentNamesAllowing("rollback")))
+	}
+
+	// Checks if the user's role allows rollback in the namespace
+	if err := botConfig.authorize(command.UserID, env, "rollback"); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Access denied: %s.", err))
+	}
+
+	// Checks that the environment is not frozen, unless an admin overrides the freeze
+	if err := checkFreeze(env, command.UserID, "/rollback", force); err != nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("%s.", err))
+	}
+	namespace := env.Namespace
+
+	// Checks if the app is registered, so the right GitOps files are updated
+	app := botConfig.app(label)
+	if app == nil {
+		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("App `%s` is not registered for rollback. Please choose from: %s.", label, botConfig.appNames()))
 	}
 
 	// Retrieves the current deployed version in the namespace with the specified label
@@ -340,26 +440,68 @@ func handleRollbackCommand(command slack.SlashCommand, client *slack.Client) (in
 		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("No pods with label `%s` found in namespace `%s`.", label, namespace))
 	}
 
+	return performRollback(app, env, label, currentVersion, "", command.Command+" "+command.Text, client, command.ChannelID, command.UserID, false)
+}
+
+// performRollback rolls the app back from currentVersion to the version
+// released before it: it updates the version in the GitOps repository, records
+// the rollback in the history and watches the rollout. automatic is set when
+// the bot rolls back by itself, e.g. after a failed soak, in which case userID
+// is only notified, the history has no actor and details tells why.
+func performRollback(app *App, env *Environment, label, currentVersion, details, commandText string, client *slack.Client, channelID, userID string, automatic bool) (interface{}, error) {
+	namespace := env.Namespace
+	actor, commandType := userID, fmt.Sprintf("Rollback %s", label)
+	if automatic {
+		actor, commandType = "", fmt.Sprintf("Automatic rollback %s", label)
+	}
+
 	// Retrieves the version to roll back to from the release history
-	rollbackVersion, err := getPreviousVersion(namespace, currentVersion, label) // Виправлено параметри функції
+	rollbackVersion, err := store.GetPreviousVersion(namespace, currentVersion, label) // Виправлено параметри функції
 	if err != nil {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to determine rollback version for namespace `%s`: %s", namespace, err))
+		return sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("Failed to determine rollback version for namespace `%s`: %s", namespace, err))
 	}
 
 	if rollbackVersion == "" {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, "No previous version found for rollback.")
+		return sendErrorMessage(client, channelID, userID, commandText, "No previous version found for rollback.")
 	}
 
 	// Initiates the rollback process to the previous version
-	err = updateVersionInGitHubFile(namespace, rollbackVersion, fmt.Sprintf("Rollback %s", label))
+	pr, err := updateVersionInGitHubFile(app, env, rollbackVersion, commandType, userID)
+	if errors.Is(err, errAlreadyAtVersion) {
+		return sendSuccessMessage(client, channelID, userID, commandText, fmt.Sprintf("Version `%s` is already set for namespace `%s` in the GitOps repository. Nothing to roll back.", rollbackVersion, namespace))
+	}
 	if err != nil {
-		return sendErrorMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Failed to rollback to version `%s` in namespace `%s`: %s", rollbackVersion, namespace, err))
+		return sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("Failed to rollback to version `%s` in namespace `%s`: %s", rollbackVersion, namespace, err))
 	}
+	release := Release{Namespace: namespace, Version: rollbackVersion, Label: label, Action: historyRollback, Actor: actor, Details: details}
 
-	// Asynchronously checks the status of pods after the rollback operation
-	go checkPodStatusAfterPromotion(namespace, rollbackVersion, command.Command+" "+command.Text, client, command.ChannelID, command.UserID)
+	// In the pull request mode, the rollback is recorded and the pods are watched only once the pull request is merged
+	if pr != nil {
+		go waitForPullRequestMerge(app, pr, commandText, client, channelID, userID, func() {
+			if err := reconcileAfterCommit(app, env); err != nil {
+				sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("The change will be applied at the next Flux interval: %s", err))
+			}
+			go trackRollout(namespace, label, rollbackVersion, commandText, client, channelID, userID)
+			if err := store.AddReleaseHistory(release); err != nil {
+				sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("Failed to record the rollback in the history: %s", err))
+			}
+		})
+		return sendSuccessMessage(client, channelID, userID, commandText, fmt.Sprintf("Pull request <%s|#%d> to rollback to version `%s` in namespace `%s` has been opened. The deployment will be watched once it is merged.", pr.GetHTMLURL(), pr.GetNumber(), rollbackVersion, namespace))
+	}
 
-	return sendSuccessMessage(client, command.ChannelID, command.UserID, command.Command+" "+command.Text, fmt.Sprintf("Rollback to version `%s` in namespace `%s` has been initiated. Please wait for the deployment to complete.", rollbackVersion, namespace))
+	message := fmt.Sprintf("Rollback to version `%s` in namespace `%s` has been initiated. Please wait for the deployment to complete.", rollbackVersion, namespace)
+	if err := reconcileAfterCommit(app, env); err != nil {
+		message += fmt.Sprintf("\n:warning: The change will be applied at the next Flux interval: %s", err)
+	}
+
+	// Asynchronously tracks the rollout of the deployment after the rollback operation
+	go trackRollout(namespace, label, rollbackVersion, commandText, client, channelID, userID)
+
+	if err := store.AddReleaseHistory(release); err != nil {
+		return sendErrorMessage(client, channelID, userID, commandText, fmt.Sprintf("%s\nFailed to record the rollback in the history: %s", message, err))
+	}
+
+	return sendSuccessMessage(client, channelID, userID, commandText, message)
 }
 
 // sendErrorMessage sends an error message to the user in Slack.
@@ -470,6 +612,15 @@ func handleInteractionEvent(interaction slack.InteractionCallback, client *slack
 			log.Printf("%+v", action)
 			log.Println("Selected option: ", action.SelectedOptions)
 
+			switch action.ActionID {
+			case approveActionID, rejectActionID:
+				if err := handleApprovalAction(action, interaction, client); err != nil {
+					log.Printf("Failed to handle approval action: %v", err)
+					if err := postEphemeral(client, interaction.Channel.ID, interaction.User.ID, fmt.Sprintf("Failed to handle the approval: %s.", err)); err != nil {
+						log.Println(err)
+					}
+				}
+			}
 		}
 
 	default:
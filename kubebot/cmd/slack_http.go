@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// maxSlackRequestBody caps the size of the requests accepted from Slack.
+const maxSlackRequestBody = 1 << 20
+
+// runHTTPMode serves slash commands, events and interactivity over HTTP(S)
+// until the context is cancelled. Every request must carry a valid Slack
+// signature made with the signing secret.
+func runHTTPMode(ctx context.Context, client *slack.Client, addr, signingSecret, tlsCert, tlsKey string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/slack/commands", verifySlackSignature(signingSecret, slashCommandHandler(client)))
+	mux.Handle("/slack/events", verifySlackSignature(signingSecret, eventsHandler(client)))
+	mux.Handle("/slack/interactivity", verifySlackSignature(signingSecret, interactivityHandler(client)))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down HTTP listener")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if tlsCert != "" {
+		log.Printf("Serving Slack requests over HTTPS on %s", addr)
+		err = server.ListenAndServeTLS(tlsCert, tlsKey)
+	} else {
+		log.Printf("Serving Slack requests over HTTP on %s", addr)
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve Slack requests: %w", err)
+	}
+	return nil
+}
+
+// verifySlackSignature rejects requests without a valid X-Slack-Signature
+// and hands the verified body on to the next handler.
+func verifySlackSignature(signingSecret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackRequestBody))
+		if err != nil {
+			http.Error(w, "failed to read request", http.StatusBadRequest)
+			return
+		}
+
+		verifier, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+		if err != nil {
+			log.Printf("Rejected Slack request to %s: %v", r.URL.Path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		verifier.Write(body)
+		if err := verifier.Ensure(); err != nil {
+			log.Printf("Rejected Slack request to %s: %v", r.URL.Path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// slashCommandHandler acknowledges slash commands right away, as Slack expects
+// a response within three seconds, and handles them in the background.
+func slashCommandHandler(client *slack.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		command, err := slack.SlashCommandParse(r)
+		if err != nil {
+			http.Error(w, "invalid slash command", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		go func() {
+			if _, err := handleSlashCommand(command, client); err != nil {
+				log.Printf("Failed to handle slash command %s: %v", command.Command, err)
+			}
+		}()
+	})
+}
+
+// eventsHandler answers the Events API URL verification challenge and handles
+// callback events in the background.
+func eventsHandler(client *slack.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+		if err != nil {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+
+		if event.Type == slackevents.URLVerification {
+			var challenge slackevents.ChallengeResponse
+			if err := json.Unmarshal(body, &challenge); err != nil {
+				http.Error(w, "invalid challenge", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(challenge.Challenge))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		go func() {
+			if err := handleEventMessage(event, client); err != nil {
+				log.Printf("Failed to handle event %s: %v", event.Type, err)
+			}
+		}()
+	})
+}
+
+// interactivityHandler handles button clicks and other interactions in the background.
+func interactivityHandler(client *slack.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var interaction slack.InteractionCallback
+		if err := json.Unmarshal([]byte(r.PostFormValue("payload")), &interaction); err != nil {
+			http.Error(w, "invalid interaction payload", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		go func() {
+			if err := handleInteractionEvent(interaction, client); err != nil {
+				log.Printf("Failed to handle interaction %s: %v", interaction.Type, err)
+			}
+		}()
+	})
+}
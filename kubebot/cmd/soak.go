@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// soakCheckInterval is how often the pods of a version are checked during its soak.
+const soakCheckInterval = 15 * time.Second
+
+// SoakConfig describes how a promoted version is watched once its rollout
+// completes, and when it is rolled back automatically.
+type SoakConfig struct {
+	// Duration is how long the pods of a promoted version are watched after the
+	// rollout. Zero disables the soak.
+	Duration time.Duration `yaml:"duration"`
+	// MaxRestarts is how many container restarts of the new pods are tolerated
+	// during the soak. Defaults to 3; 0 tolerates none.
+	MaxRestarts *int32 `yaml:"maxRestarts"`
+	// MaxUnready is how long a new pod may stay not ready during the soak.
+	// Defaults to 2 minutes; 0 fails the soak as soon as a pod is not ready.
+	MaxUnready *time.Duration `yaml:"maxUnready"`
+}
+
+// soakResult is the health of the pods of a version at one check of its soak.
+type soakResult struct {
+	// pods is the number of pods running the version.
+	pods int
+	// ready is the number of those pods that are ready.
+	ready int
+	// restarts is the number of container restarts since the soak started.
+	restarts int32
+	// problems lists the thresholds that were exceeded, empty while the version is healthy.
+	problems []string
+}
+
+// watchPromotion follows the rollout of a promoted version and soaks it once
+// the rollout completes.
+func watchPromotion(app *App, env *Environment, label, version, command string, client *slack.Client, channelID, userID string) {
+	if trackRollout(env.Namespace, label, version, command, client, channelID, userID) {
+		soakRollout(app, env, label, version, command, client, channelID, userID)
+	}
+}
+
+// soakRollout watches the pods of a version for the soak duration of the
+// environment, and rolls back to the previous version as soon as they restart
+// too often, crash loop or stay not ready for too long.
+func soakRollout(app *App, env *Environment, label, version, command string, client *slack.Client, channelID, userID string) {
+	soak := env.Soak
+	if soak.Duration == 0 {
+		return
+	}
+	activeWatches.Inc()
+	defer activeWatches.Dec()
+
+	ctx, cancel := context.WithTimeout(lifecycleCtx, soak.Duration)
+	defer cancel()
+
+	namespace := env.Namespace
+	started := time.Now()
+	baseline := make(map[string]int32)
+	progressText := fmt.Sprintf("Soak of `%s` version `%s` in namespace `%s` for %s: waiting for the first check.", label, version, namespace, soak.Duration)
+	progressTS := postProgressMessage(client, channelID, command, progressText)
+	ticker := time.NewTicker(soakCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				log.Printf("Stopped the soak of %s version %s in namespace %s: the bot is shutting down", label, version, namespace)
+				return
+			}
+			updateProgressMessage(client, channelID, progressTS, command, fmt.Sprintf("Soak of `%s` version `%s` in namespace `%s` passed.", label, version, namespace))
+			sendSuccessMessage(client, channelID, userID, command, fmt.Sprintf("Version `%s` of `%s` in namespace `%s` stayed healthy for %s.", version, label, namespace, soak.Duration))
+			return
+		case <-ticker.C:
+			pods, err := listPods(namespace, appSelector(label))
+			if err != nil {
+				log.Printf("Failed to list pods of %s in namespace %s during the soak: %v", label, namespace, err)
+				continue
+			}
+
+			now := time.Now()
+			result := checkSoak(pods, label, version, soak, started, baseline, now)
+			if result.pods == 0 {
+				// Another promotion or rollback replaced the version, there is nothing left to soak
+				updateProgressMessage(client, channelID, progressTS, command, fmt.Sprintf("Soak of `%s` version `%s` in namespace `%s` stopped: no pods run the version anymore.", label, version, namespace))
+				return
+			}
+
+			progressText = fmt.Sprintf("Soak of `%s` version `%s` in namespace `%s`: %d/%d ready, %d restarts, %s left.", label, version, namespace, result.ready, result.pods, result.restarts, (soak.Duration - now.Sub(started)).Round(time.Second))
+			updateProgressMessage(client, channelID, progressTS, command, progressText)
+			if len(result.problems) == 0 {
+				continue
+			}
+
+			updateProgressMessage(client, channelID, progressTS, command, fmt.Sprintf("Soak of `%s` version `%s` in namespace `%s` failed after %s.", label, version, namespace, now.Sub(started).Round(time.Second)))
+			var states []string
+			for _, pod := range pods {
+				states = append(states, describePodState(pod))
+			}
+			sendErrorMessage(client, channelID, userID, command, fmt.Sprintf(":rotating_light: Version `%s` of `%s` in namespace `%s` failed its soak after %s:\n• %s\nPod states:\n%s\nRolling back to the previous version.",
+				version, label, namespace, now.Sub(started).Round(time.Second), strings.Join(result.problems, "\n• "), strings.Join(states, "\n")))
+
+			details := fmt.Sprintf("automatic rollback of %s after a failed soak: %s", version, strings.Join(result.problems, "; "))
+			performRollback(app, env, label, version, details, command, client, channelID, userID, true)
+			return
+		}
+	}
+}
+
+// checkSoak evaluates the pods of an app against the soak thresholds. Only the
+// pods running the version count; restarts of pods that existed before the
+// soak started are counted from the baseline recorded the first time they are seen.
+func checkSoak(pods []*corev1.Pod, label, version string, soak SoakConfig, started time.Time, baseline map[string]int32, now time.Time) soakResult {
+	var result soakResult
+	var restarted []string
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if podVersion, err := appVersion(pod.Spec, label); err != nil || podVersion != version {
+			continue
+		}
+		result.pods++
+
+		restarts := int32(0)
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+			if waiting := status.State.Waiting; waiting != nil && waiting.Reason == "CrashLoopBackOff" {
+				problem := fmt.Sprintf("Container `%s` of pod `%s` is in CrashLoopBackOff", status.Name, pod.Name)
+				if terminated := status.LastTerminationState.Terminated; terminated != nil {
+					problem += fmt.Sprintf(", last exit: %s (exit code %d)", terminated.Reason, terminated.ExitCode)
+				}
+				result.problems = append(result.problems, problem)
+			}
+		}
+		if _, ok := baseline[pod.Name]; !ok {
+			if pod.CreationTimestamp.Time.Before(started) {
+				baseline[pod.Name] = restarts
+			} else {
+				baseline[pod.Name] = 0
+			}
+		}
+		if delta := restarts - baseline[pod.Name]; delta > 0 {
+			result.restarts += delta
+			restarted = append(restarted, fmt.Sprintf("`%s` x%d", pod.Name, delta))
+		}
+
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady {
+				continue
+			}
+			if condition.Status == corev1.ConditionTrue {
+				result.ready++
+				break
+			}
+			// Time spent not ready before the soak started belongs to the rollout
+			since := condition.LastTransitionTime.Time
+			if since.Before(started) {
+				since = started
+			}
+			if unready := now.Sub(since); unready > *soak.MaxUnready {
+				result.problems = append(result.problems, fmt.Sprintf("Pod `%s` has not been ready for %s (more than %s allowed)", pod.Name, unready.Round(time.Second), *soak.MaxUnready))
+			}
+		}
+	}
+
+	if result.restarts > *soak.MaxRestarts {
+		sort.Strings(restarted)
+		result.problems = append(result.problems, fmt.Sprintf("%d container restarts since the soak started (more than %d allowed): %s", result.restarts, *soak.MaxRestarts, strings.Join(restarted, ", ")))
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testPod returns a pod of the kbot app running the image version, with one
+// container that restarted the given number of times.
+func testPod(name, version string, created time.Time, restarts int32, ready bool, readySince time.Time) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created), Labels: map[string]string{"app.kubernetes.io/name": "kbot"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "kbot", Image: "ghcr.io/team/kbot:" + version}}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "kbot", RestartCount: restarts}},
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus, LastTransitionTime: metav1.NewTime(readySince)}},
+		},
+	}
+}
+
+func TestCheckSoak(t *testing.T) {
+	previous := botConfig
+	botConfig = &Config{}
+	t.Cleanup(func() { botConfig = previous })
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := started.Add(5 * time.Minute)
+	maxRestarts, maxUnready := int32(3), 2*time.Minute
+	soak := SoakConfig{Duration: 30 * time.Minute, MaxRestarts: &maxRestarts, MaxUnready: &maxUnready}
+	var zeroRestarts int32
+	var zeroUnready time.Duration
+	strict := SoakConfig{Duration: 30 * time.Minute, MaxRestarts: &zeroRestarts, MaxUnready: &zeroUnready}
+	crashLooping := testPod("kbot-c", "v2", started.Add(time.Minute), 1, false, started.Add(4*time.Minute))
+	crashLooping.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+	crashLooping.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}
+
+	tests := []struct {
+		name         string
+		pods         []*corev1.Pod
+		baseline     map[string]int32
+		soak         *SoakConfig
+		wantPods     int
+		wantReady    int
+		wantRestarts int32
+		wantProblems []string
+	}{
+		{
+			name:      "healthy",
+			pods:      []*corev1.Pod{testPod("kbot-a", "v2", started.Add(-time.Minute), 0, true, started), testPod("kbot-b", "v2", started.Add(time.Minute), 0, true, started.Add(2*time.Minute))},
+			wantPods:  2,
+			wantReady: 2,
+		},
+		{
+			name:      "pods of other versions are ignored",
+			pods:      []*corev1.Pod{testPod("kbot-a", "v2", started, 0, true, started), testPod("kbot-old", "v1", started.Add(-time.Hour), 9, false, started.Add(-time.Hour))},
+			wantPods:  1,
+			wantReady: 1,
+		},
+		{
+			name:         "restarts before the soak are not counted",
+			pods:         []*corev1.Pod{testPod("kbot-a", "v2", started.Add(-time.Minute), 2, true, started)},
+			baseline:     map[string]int32{"kbot-a": 1},
+			wantPods:     1,
+			wantReady:    1,
+			wantRestarts: 1,
+		},
+		{
+			name:         "too many restarts",
+			pods:         []*corev1.Pod{testPod("kbot-a", "v2", started.Add(time.Minute), 2, true, now), testPod("kbot-b", "v2", started.Add(time.Minute), 2, true, now)},
+			wantPods:     2,
+			wantReady:    2,
+			wantRestarts: 4,
+			wantProblems: []string{"4 container restarts since the soak started (more than 3 allowed)"},
+		},
+		{
+			name:         "not ready for too long",
+			pods:         []*corev1.Pod{testPod("kbot-a", "v2", started.Add(time.Minute), 0, false, started.Add(time.Minute))},
+			wantPods:     1,
+			wantProblems: []string{"Pod `kbot-a` has not been ready for 4m0s"},
+		},
+		{
+			name:      "not ready before the soak started counts from its start",
+			pods:      []*corev1.Pod{testPod("kbot-a", "v2", started.Add(-time.Hour), 0, false, started.Add(-time.Hour))},
+			wantPods:  1,
+			wantReady: 0,
+			wantProblems: []string{
+				"Pod `kbot-a` has not been ready for 5m0s",
+			},
+		},
+		{
+			name:         "crash loop",
+			pods:         []*corev1.Pod{crashLooping},
+			wantPods:     1,
+			wantRestarts: 1,
+			wantProblems: []string{"Container `kbot` of pod `kbot-c` is in CrashLoopBackOff, last exit: Error (exit code 1)"},
+		},
+		{
+			name:         "explicit zero thresholds",
+			pods:         []*corev1.Pod{testPod("kbot-a", "v2", started.Add(time.Minute), 1, false, now.Add(-time.Second))},
+			soak:         &strict,
+			wantPods:     1,
+			wantRestarts: 1,
+			wantProblems: []string{
+				"Pod `kbot-a` has not been ready for 1s (more than 0s allowed)",
+				"1 container restarts since the soak started (more than 0 allowed)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := tt.baseline
+			if baseline == nil {
+				baseline = make(map[string]int32)
+			}
+			soak := soak
+			if tt.soak != nil {
+				soak = *tt.soak
+			}
+			got := checkSoak(tt.pods, "kbot", "v2", soak, started, baseline, now)
+			if got.pods != tt.wantPods || got.ready != tt.wantReady || got.restarts != tt.wantRestarts {
+				t.Errorf("pods, ready, restarts = %d, %d, %d, want %d, %d, %d", got.pods, got.ready, got.restarts, tt.wantPods, tt.wantReady, tt.wantRestarts)
+			}
+			if len(got.problems) != len(tt.wantProblems) {
+				t.Fatalf("problems = %q, want %q", got.problems, tt.wantProblems)
+			}
+			for i, want := range tt.wantProblems {
+				if !strings.HasPrefix(got.problems[i], want) {
+					t.Errorf("problem %d = %q, want it to start with %q", i, got.problems[i], want)
+				}
+			}
+		})
+	}
+}
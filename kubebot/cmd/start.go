@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
@@ -12,9 +14,21 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// lifecycleCtx is cancelled when the bot shuts down, stopping the background
+// watches started by the command handlers.
+var lifecycleCtx = context.Background()
+
+// Flags of the start command selecting how Slack reaches the bot.
+var (
+	transport  string
+	listenAddr string
+	tlsCert    string
+	tlsKey     string
+)
+
 // startCmd represents the start command for the Slackbot. It initializes and starts the Slackbot,
-// setting up the necessary clients for Slack API and Socket Mode, as well as initializing the database,
-// Kubernetes, and GitHub clients.
+// setting up the necessary clients for Slack API and either Socket Mode or the HTTP endpoints, as
+// well as initializing the database, Kubernetes, and GitHub clients.
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Starts the Slackbot",
@@ -22,82 +36,131 @@ var startCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Slackbot started")
 
+		// Check the environment variables shared by both transports
+		checkStartEnv()
+
 		// Retrieve Slack API and App-Level tokens from environment variables
 		token := os.Getenv("SLACK_AUTH_TOKEN")
 		appToken := os.Getenv("SLACK_APP_TOKEN")
+		signingSecret := os.Getenv("SLACK_SIGNING_SECRET")
+
+		// Check the credentials of the selected transport before anything is initialized
+		switch transport {
+		case "socket":
+			if appToken == "" {
+				log.Fatal("Environment variable SLACK_APP_TOKEN is not set. It is required by --transport=socket.")
+			}
+		case "http":
+			if signingSecret == "" {
+				log.Fatal("Environment variable SLACK_SIGNING_SECRET is not set. It is required by --transport=http.")
+			}
+			if (tlsCert == "") != (tlsKey == "") {
+				log.Fatal("--tls-cert and --tls-key must be set together")
+			}
+		default:
+			log.Fatalf("Unknown transport %q. Please choose from: socket, http.", transport)
+		}
 
 		// Initialize Slack client with debugging enabled
 		client := slack.New(token, slack.OptionDebug(true), slack.OptionAppLevelToken(appToken))
-		socketClient := socketmode.New(
-			client,
-			socketmode.OptionDebug(true),
-			socketmode.OptionLog(log.New(os.Stdout, "socketmode: ", log.Lshortfile|log.LstdFlags)),
-		)
-
-		// Create a context to manage the lifecycle of the Socket Mode listener
-		ctx, cancel := context.WithCancel(context.Background())
+
+		// Create a context to manage the lifecycle of the Slack listener and the background watches.
+		// It is cancelled when the bot is asked to stop.
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
+		lifecycleCtx = ctx
 
-		// Initialize the database, Kubernetes client, and GitHub client
+		// Load the environments config, then initialize the database, Kubernetes client and cache, and GitHub client
+		initConfig()
 		initDatabase()
-		initKubernetesClient()
+		// Every command reads the cluster, and the cache needs a client to start its informers
+		if err := initKubernetesClient(); err != nil {
+			log.Fatalf("Failed to initialize the Kubernetes client: %v", err)
+		}
+		initKubernetesCache(ctx)
 		initGitHubClient()
 		go startMetricsServer()
+		go startApprovalExpiry(ctx, client)
+		go startFreezeExpiry(ctx, client)
+		go startHealthAlerts(ctx, client)
+		go startDriftDetection(ctx, client)
 
+		if transport == "http" {
+			if err := runHTTPMode(ctx, client, listenAddr, signingSecret, tlsCert, tlsKey); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		runSocketMode(ctx, client)
+	},
+}
+
+// runSocketMode listens for Slack events over a Socket Mode websocket and hands them to the handlers.
+func runSocketMode(ctx context.Context, client *slack.Client) {
+	socketClient := socketmode.New(
+		client,
+		socketmode.OptionDebug(true),
+		socketmode.OptionLog(log.New(os.Stdout, "socketmode: ", log.Lshortfile|log.LstdFlags)),
+	)
 
-		// Start a goroutine to listen for and handle incoming events from Slack
-		go func(ctx context.Context, client *slack.Client, socketClient *socketmode.Client) {
-			for {
-				select {
-				case <-ctx.Done():
-					log.Println("Shutting down socketmode listener")
-					return
-				case event := <-socketClient.Events:
-					switch event.Type {
-					case socketmode.EventTypeEventsAPI:
-						eventsAPIEvent, ok := event.Data.(slackevents.EventsAPIEvent)
-						if !ok {
-							log.Printf("Could not type cast the event to the EventsAPIEvent: %v\n", event)
-							continue
-						}
-						socketClient.Ack(*event.Request)
-						err := handleEventMessage(eventsAPIEvent, client)
-						if err != nil {
-							log.Fatal(err)
-						}
-					case socketmode.EventTypeSlashCommand:
-						command, ok := event.Data.(slack.SlashCommand)
-						if !ok {
-							log.Printf("Could not type cast the message to a SlashCommand: %v\n", command)
-							continue
-						}
-						payload, err := handleSlashCommand(command, client)
-						if err != nil {
-							log.Fatal(err)
-						}
-						socketClient.Ack(*event.Request, payload)
-					case socketmode.EventTypeInteractive:
-						interaction, ok := event.Data.(slack.InteractionCallback)
-						if !ok {
-							log.Printf("Could not type cast the message to an Interaction callback: %v\n", interaction)
-							continue
-						}
-						err := handleInteractionEvent(interaction, client)
-						if err != nil {
-							log.Fatal(err)
-						}
-						socketClient.Ack(*event.Request)
+	// Start a goroutine to listen for and handle incoming events from Slack
+	go func(ctx context.Context, client *slack.Client, socketClient *socketmode.Client) {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("Shutting down socketmode listener")
+				return
+			case event := <-socketClient.Events:
+				switch event.Type {
+				case socketmode.EventTypeEventsAPI:
+					eventsAPIEvent, ok := event.Data.(slackevents.EventsAPIEvent)
+					if !ok {
+						log.Printf("Could not type cast the event to the EventsAPIEvent: %v\n", event)
+						continue
+					}
+					socketClient.Ack(*event.Request)
+					if err := handleEventMessage(eventsAPIEvent, client); err != nil {
+						log.Printf("Failed to handle event %s: %v", eventsAPIEvent.Type, err)
+					}
+				case socketmode.EventTypeSlashCommand:
+					command, ok := event.Data.(slack.SlashCommand)
+					if !ok {
+						log.Printf("Could not type cast the message to a SlashCommand: %v\n", command)
+						continue
 					}
+					// Ack even if the handler failed, so that Slack does not show a timeout
+					payload, err := handleSlashCommand(command, client)
+					if err != nil {
+						log.Printf("Failed to handle slash command %s: %v", command.Command, err)
+					}
+					socketClient.Ack(*event.Request, payload)
+				case socketmode.EventTypeInteractive:
+					interaction, ok := event.Data.(slack.InteractionCallback)
+					if !ok {
+						log.Printf("Could not type cast the message to an Interaction callback: %v\n", interaction)
+						continue
+					}
+					// Ack even if the handler failed, so that Slack does not retry the interaction
+					if err := handleInteractionEvent(interaction, client); err != nil {
+						log.Printf("Failed to handle interaction %s: %v", interaction.Type, err)
+					}
+					socketClient.Ack(*event.Request)
 				}
 			}
-		}(ctx, client, socketClient)
+		}
+	}(ctx, client, socketClient)
 
-		// Start the Socket Mode client to listen for incoming events
-		socketClient.Run()
-	},
+	// Start the Socket Mode client to listen for incoming events until the context is cancelled
+	if err := socketClient.RunContext(ctx); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
 }
 
 // Register the start command to the root command of the CLI application
 func init() {
+	startCmd.Flags().StringVar(&transport, "transport", "socket", "how Slack reaches the bot: socket (Socket Mode, needs SLACK_APP_TOKEN) or http (Events API endpoints, needs SLACK_SIGNING_SECRET)")
+	startCmd.Flags().StringVar(&listenAddr, "listen", ":8080", "address the http transport listens on")
+	startCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate file for the http transport; serves plain HTTP when empty, e.g. behind an ingress")
+	startCmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS key file for the http transport")
 	rootCmd.AddCommand(startCmd)
 }